@@ -73,14 +73,25 @@ func main() {
 	defer pg.DB.Close()
 
 	// ---------- repositories ----------
+	txManager := repo.NewTxManager(pg.DB)
 	subRepo := repo.NewSubscriptionPostgres(pg.DB)
+	rateRepo := repo.NewExchangeRatePostgres(pg.DB)
+	idemRepo := repo.NewIdempotencyPostgres(pg.DB)
+	eventRepo := repo.NewSubscriptionEventPostgres(pg.DB)
+	serviceRepo := repo.NewServicePostgres(pg.DB)
 
 	// ---------- services ----------
-	subService := service.NewSubscriptionService(subRepo)
+	rateService := service.NewExchangeRateService(rateRepo)
+	converter := service.NewCurrencyConverter(rateRepo)
+	subService := service.NewSubscriptionService(subRepo, eventRepo, serviceRepo, txManager, converter)
+	catalogService := service.NewCatalogService(serviceRepo, subRepo, eventRepo, txManager)
+	idemService := service.NewIdempotencyService(idemRepo, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
 
 	// ---------- handlers ----------
 	subHandler := handlers.NewSubscriptionHandler(subService)
 	totalHandler := handlers.NewTotalHandler(subService)
+	rateHandler := handlers.NewExchangeRateHandler(rateService)
+	catalogHandler := handlers.NewCatalogHandler(catalogService)
 
 	// ---------- gin ----------
 	if cfg.Env == "prod" {
@@ -88,7 +99,12 @@ func main() {
 	}
 
 	r := gin.New()
-	r.Use(gin.Recovery())
+	r.HandleMethodNotAllowed = true
+	r.Use(httpserver.RequestID())
+	r.Use(httpserver.Actor())
+	r.Use(gin.CustomRecovery(handlers.Recovery))
+	r.NoRoute(handlers.NoRoute)
+	r.NoMethod(handlers.NoMethod)
 
 	// ---------- health ----------
 	r.GET("/health", func(c *gin.Context) {
@@ -108,13 +124,43 @@ func main() {
 	// ---------- API v1 ----------
 	api := r.Group("/api/v1")
 	{
-		api.POST("/subscriptions", subHandler.Create)
+		api.POST("/subscriptions", handlers.Idempotency(idemService), subHandler.Create)
+		api.POST("/subscriptions/batch", handlers.Idempotency(idemService), subHandler.Batch)
 		api.GET("/subscriptions/:id", subHandler.GetByID)
 		api.PUT("/subscriptions/:id", subHandler.Update)
+		api.PATCH("/subscriptions/:id", subHandler.Patch)
 		api.DELETE("/subscriptions/:id", subHandler.Delete)
+		api.POST("/subscriptions/:id/restore", subHandler.Restore)
+		api.GET("/subscriptions/:id/history", subHandler.History)
+		api.GET("/subscriptions/:id/prices", subHandler.Prices)
+		api.POST("/subscriptions/:id/prices", subHandler.ChangePrice)
+		api.DELETE("/subscriptions/:id/prices/:effective_from", subHandler.CancelPriceChange)
+		api.POST("/subscriptions/:id/plans", subHandler.ChangePlan)
+		api.DELETE("/subscriptions/:id/plans/:effective_from", subHandler.CancelPlanChange)
+		api.POST("/subscriptions/:id/pause", subHandler.Pause)
+		api.POST("/subscriptions/:id/resume", subHandler.Resume)
+		api.POST("/subscriptions/:id/discounts", subHandler.AddDiscount)
+		api.DELETE("/subscriptions/:id/discounts/:discount_id", subHandler.RemoveDiscount)
 		api.GET("/subscriptions", subHandler.List)
+		api.GET("/subscriptions/trash", subHandler.Trash)
+		api.GET("/subscriptions/trials/ending", subHandler.TrialsEnding)
 
 		api.GET("/subscriptions/total", totalHandler.Get)
+		api.GET("/subscriptions/breakdown", totalHandler.Breakdown)
+
+		api.GET("/services", catalogHandler.List)
+		api.POST("/services", catalogHandler.Create)
+		api.GET("/services/:id", catalogHandler.GetByID)
+		api.PUT("/services/:id", catalogHandler.Update)
+		api.DELETE("/services/:id", catalogHandler.Delete)
+		api.POST("/services/:id/plans", catalogHandler.CreatePlan)
+		api.PUT("/services/:id/plans/:plan_id", catalogHandler.UpdatePlan)
+		api.DELETE("/services/:id/plans/:plan_id", catalogHandler.DeletePlan)
+
+		api.GET("/rates", rateHandler.List)
+		api.POST("/rates/import", rateHandler.Import)
+		api.PUT("/rates/:base/:quote", rateHandler.Set)
+		api.DELETE("/rates/:base/:quote", rateHandler.Delete)
 	}
 
 	// ---------- http server ----------
@@ -138,12 +184,24 @@ func main() {
 		}
 	}()
 
+	// ---------- background jobs ----------
+	jobsCtx, stopJobs := context.WithCancel(ctx)
+	defer stopJobs()
+
+	go runPeriodically(jobsCtx, logger, "idempotency key purge", cfg.Idempotency.PurgeInterval,
+		idemService.PurgeExpired)
+	go runPeriodically(jobsCtx, logger, "deleted subscription purge", cfg.SoftDelete.PurgeInterval,
+		func(ctx context.Context) (int64, error) {
+			return subService.PurgeDeleted(ctx, cfg.SoftDelete.Retention)
+		})
+
 	// ---------- graceful shutdown ----------
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 	<-stop
 
 	logger.Info("shutdown signal received")
+	stopJobs()
 
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
@@ -154,3 +212,24 @@ func main() {
 		logger.Info("server stopped gracefully")
 	}
 }
+
+// runPeriodically calls job every interval until ctx is done, logging how
+// many rows each run affected.
+func runPeriodically(ctx context.Context, logger *slog.Logger, name string, interval time.Duration, job func(context.Context) (int64, error)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := job(ctx)
+			if err != nil {
+				logger.Error(name+" failed", "err", err)
+				continue
+			}
+			logger.Debug(name+" done", "count", n)
+		}
+	}
+}
@@ -13,14 +13,17 @@ type Config struct {
 	HTTP HTTP   `yaml:"http"`
 	DB   DB     `yaml:"db"`
 	Log  Log    `yaml:"log"`
+
+	Idempotency Idempotency `yaml:"idempotency"`
+	SoftDelete  SoftDelete  `yaml:"soft_delete"`
 }
 
 type HTTP struct {
-	Host          string        `yaml:"host" env:"HTTP_HOST" env-default:"0.0.0.0"`
-	Port          int           `yaml:"port" env:"HTTP_PORT" env-default:"8080"`
-	ReadTimeout   time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"5s"`
-	WriteTimeout  time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"5s"`
-	IdleTimeout   time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	Host            string        `yaml:"host" env:"HTTP_HOST" env-default:"0.0.0.0"`
+	Port            int           `yaml:"port" env:"HTTP_PORT" env-default:"8080"`
+	ReadTimeout     time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"5s"`
+	WriteTimeout    time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"5s"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
 	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" env:"HTTP_SHUTDOWN_TIMEOUT" env-default:"10s"`
 }
 
@@ -37,6 +40,21 @@ type DB struct {
 	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" env-default:"30m"`
 }
 
+type Idempotency struct {
+	// TTL is how long a key is remembered after its first use.
+	TTL time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	// Lease is how long a request in progress holds its key; a retry
+	// after it may run the request again.
+	Lease         time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE" env-default:"1m"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"IDEMPOTENCY_PURGE_INTERVAL" env-default:"1h"`
+}
+
+type SoftDelete struct {
+	// Retention is how long deleted subscriptions stay restorable.
+	Retention     time.Duration `yaml:"retention" env:"SOFT_DELETE_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"SOFT_DELETE_PURGE_INTERVAL" env-default:"1h"`
+}
+
 type Log struct {
 	Level  string `yaml:"level" env:"LOG_LEVEL" env-default:"info"`
 	Format string `yaml:"format" env:"LOG_FORMAT" env-default:"json"`
@@ -0,0 +1,70 @@
+package domain
+
+import "time"
+
+type BillingUnit string
+
+const (
+	BillingWeek  BillingUnit = "week"
+	BillingMonth BillingUnit = "month"
+	BillingYear  BillingUnit = "year"
+)
+
+// weeksPerMonth is the average number of weeks in a month (52 / 12).
+const weeksPerMonth = 52.0 / 12.0
+
+// BillingInterval is the period between two charges, e.g. 3 months for
+// quarterly billing.
+type BillingInterval struct {
+	Unit  BillingUnit
+	Count int
+}
+
+// MonthlyBilling is the interval assumed when none is given.
+var MonthlyBilling = BillingInterval{Unit: BillingMonth, Count: 1}
+
+func (u BillingUnit) Valid() bool {
+	switch u {
+	case BillingWeek, BillingMonth, BillingYear:
+		return true
+	default:
+		return false
+	}
+}
+
+// Nth returns the n-th billing date counted from anchor. Month-based
+// intervals keep the anchor's day of month, clamped to the month length,
+// so a subscription started on Jan 31 is billed on Feb 28/29.
+func (i BillingInterval) Nth(anchor time.Time, n int) time.Time {
+	switch i.Unit {
+	case BillingWeek:
+		return anchor.AddDate(0, 0, 7*i.Count*n)
+	case BillingYear:
+		return addMonthsClamped(anchor, 12*i.Count*n)
+	default:
+		return addMonthsClamped(anchor, i.Count*n)
+	}
+}
+
+// Months returns the interval length in (average) months.
+func (i BillingInterval) Months() float64 {
+	switch i.Unit {
+	case BillingWeek:
+		return float64(i.Count) / weeksPerMonth
+	case BillingYear:
+		return float64(12 * i.Count)
+	default:
+		return float64(i.Count)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, 0, 0, 0, 0, t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+
+	day := t.Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(first.Year(), first.Month(), day, 0, 0, 0, 0, t.Location())
+}
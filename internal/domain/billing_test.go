@@ -0,0 +1,52 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBillingIntervalNth(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	monthly := BillingInterval{Unit: BillingMonth, Count: 1}
+
+	tests := []struct {
+		name     string
+		interval BillingInterval
+		anchor   time.Time
+		n        int
+		want     time.Time
+	}{
+		{"anchor", monthly, day(2024, time.January, 31), 0, day(2024, time.January, 31)},
+		{"clamped to leap February", monthly, day(2024, time.January, 31), 1, day(2024, time.February, 29)},
+		{"day restored after clamping", monthly, day(2024, time.January, 31), 2, day(2024, time.March, 31)},
+		{"clamped to 30 days", monthly, day(2024, time.January, 31), 3, day(2024, time.April, 30)},
+		{"clamped to February across a year", monthly, day(2024, time.December, 31), 2, day(2025, time.February, 28)},
+		{"quarterly from a month end", BillingInterval{Unit: BillingMonth, Count: 3}, day(2024, time.November, 30), 1, day(2025, time.February, 28)},
+		{"yearly from a leap day", BillingInterval{Unit: BillingYear, Count: 1}, day(2024, time.February, 29), 1, day(2025, time.February, 28)},
+		{"yearly back on a leap day", BillingInterval{Unit: BillingYear, Count: 1}, day(2024, time.February, 29), 4, day(2028, time.February, 29)},
+		{"weeks across a month", BillingInterval{Unit: BillingWeek, Count: 2}, day(2024, time.January, 25), 1, day(2024, time.February, 8)},
+	}
+
+	for _, tt := range tests {
+		if got := tt.interval.Nth(tt.anchor, tt.n); !got.Equal(tt.want) {
+			t.Errorf("%s: got %s, want %s", tt.name, got.Format(time.DateOnly), tt.want.Format(time.DateOnly))
+		}
+	}
+}
+
+func TestBillingIntervalMonths(t *testing.T) {
+	tests := []struct {
+		interval BillingInterval
+		want     float64
+	}{
+		{BillingInterval{Unit: BillingMonth, Count: 3}, 3},
+		{BillingInterval{Unit: BillingYear, Count: 2}, 24},
+		{BillingInterval{Unit: BillingWeek, Count: 13}, 3},
+	}
+
+	for _, tt := range tests {
+		if got := tt.interval.Months(); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%+v: got %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import "strings"
+
+// DefaultCurrency is assumed for subscriptions created without a currency.
+const DefaultCurrency = "RUB"
+
+// iso4217 lists active ISO 4217 alphabetic codes.
+var iso4217 = map[string]struct{}{}
+
+func init() {
+	for _, code := range strings.Fields(`
+		AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BHD BIF BMD BND
+		BOB BRL BSD BTN BWP BYN BZD CAD CDF CHF CLP CNY COP CRC CUP CVE CZK DJF
+		DKK DOP DZD EGP ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GNF GTQ GYD HKD
+		HNL HTG HUF IDR ILS INR IQD IRR ISK JMD JOD JPY KES KGS KHR KMF KPW KRW
+		KWD KYD KZT LAK LBP LKR LRD LSL LYD MAD MDL MGA MKD MMK MNT MOP MRU MUR
+		MVR MWK MXN MYR MZN NAD NGN NIO NOK NPR NZD OMR PAB PEN PGK PHP PKR PLN
+		PYG QAR RON RSD RUB RWF SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN
+		SVC SYP SZL THB TJS TMT TND TOP TRY TTD TWD TZS UAH UGX USD UYU UZS VES
+		VND VUV WST XAF XCD XCG XOF XPF YER ZAR ZMW ZWG
+	`) {
+		iso4217[code] = struct{}{}
+	}
+}
+
+// NormalizeCurrency upper-cases and trims a currency code.
+func NormalizeCurrency(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// IsCurrency reports whether code is a known ISO 4217 code.
+func IsCurrency(code string) bool {
+	_, ok := iso4217[code]
+	return ok
+}
@@ -0,0 +1,65 @@
+package domain
+
+import "time"
+
+type DiscountKind string
+
+const (
+	// DiscountPercent takes Value percent off each charge.
+	DiscountPercent DiscountKind = "percent"
+	// DiscountFixed takes Value off each charge, in the subscription
+	// currency.
+	DiscountFixed DiscountKind = "fixed"
+)
+
+func (k DiscountKind) Valid() bool {
+	switch k {
+	case DiscountPercent, DiscountFixed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Discount lowers the charges of a subscription either from StartDate to
+// EndDate inclusive (open-ended without EndDate) or, when Periods is set,
+// for the first Periods billing periods counted from the billing anchor.
+type Discount struct {
+	ID    int64
+	Kind  DiscountKind
+	Value int
+
+	StartDate *time.Time
+	EndDate   *time.Time
+	Periods   int
+}
+
+// Applies reports whether d covers the charge billed on day for the n-th
+// billing period.
+func (d Discount) Applies(day time.Time, n int) bool {
+	if d.Periods > 0 {
+		return n < d.Periods
+	}
+	return d.StartDate != nil && !day.Before(*d.StartDate) && (d.EndDate == nil || !day.After(*d.EndDate))
+}
+
+// Off returns what d takes off a charge of amount.
+func (d Discount) Off(amount float64) float64 {
+	if d.Kind == DiscountPercent {
+		return amount * float64(d.Value) / 100
+	}
+	return float64(d.Value)
+}
+
+// DiscountOn returns the discount on a charge of amount billed on day for
+// the n-th billing period: the discounts covering it add up, but never to
+// more than amount.
+func (s *Subscription) DiscountOn(day time.Time, n int, amount float64) float64 {
+	var off float64
+	for _, d := range s.Discounts {
+		if d.Applies(day, n) {
+			off += d.Off(amount)
+		}
+	}
+	return min(off, amount)
+}
@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type EventType string
+
+const (
+	EventCreated  EventType = "created"
+	EventUpdated  EventType = "updated"
+	EventDeleted  EventType = "deleted"
+	EventRestored EventType = "restored"
+)
+
+// SubscriptionEvent is one entry of a subscription's change history.
+// Before is nil for EventCreated and After for EventDeleted; Before of
+// EventRestored is the subscription as it was deleted.
+type SubscriptionEvent struct {
+	ID             int64
+	SubscriptionID uuid.UUID
+	Type           EventType
+	Before         *Subscription
+	After          *Subscription
+
+	Actor      string
+	RequestID  string
+	OccurredAt time.Time
+}
@@ -0,0 +1,14 @@
+package domain
+
+import "time"
+
+// ExchangeRate says how many units of Quote one unit of Base buys,
+// effective from Date until the next rate for the same pair.
+type ExchangeRate struct {
+	Base  string
+	Quote string
+	Date  time.Time
+	Rate  float64
+
+	UpdatedAt time.Time
+}
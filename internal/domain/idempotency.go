@@ -0,0 +1,21 @@
+package domain
+
+import "time"
+
+// IdempotentRequest is a request made under an idempotency key and, once
+// answered, the response that retries with the same key get back.
+type IdempotentRequest struct {
+	Key         string
+	RequestHash string
+
+	// StatusCode is 0 while the first request is still being processed.
+	StatusCode int
+	Header     map[string]string
+	Body       []byte
+
+	ExpiresAt time.Time
+}
+
+func (r *IdempotentRequest) Completed() bool {
+	return r.StatusCode != 0
+}
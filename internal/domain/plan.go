@@ -0,0 +1,98 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Plan is a tier of a catalog service, e.g. Basic or Family, with the price
+// charged every Billing.
+type Plan struct {
+	ID        uuid.UUID
+	ServiceID uuid.UUID
+	Name      string
+	Price     int
+	Currency  string
+	Billing   BillingInterval
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// PlanChange moves a subscription to a plan from EffectiveFrom on. It keeps
+// the name, price and interval the plan had at the time, so later edits of
+// the plan do not rewrite what was charged. PlanID is nil once the plan has
+// left the catalog.
+type PlanChange struct {
+	EffectiveFrom time.Time
+	PlanID        *uuid.UUID
+	PlanName      string
+	Price         int
+	Billing       BillingInterval
+}
+
+// PlanOn returns the plan the subscription is on on day, or nil when it is
+// on none.
+func (s *Subscription) PlanOn(day time.Time) *uuid.UUID {
+	plan := s.PlanID
+	for _, pc := range s.PlanChanges {
+		if pc.EffectiveFrom.After(day) {
+			break
+		}
+		plan = pc.PlanID
+	}
+	return plan
+}
+
+// BillingSegment is a stretch of a subscription billed at one interval: on
+// Anchor and every Billing after it, up to Until inclusive or for good when
+// Until is nil. Offset is the number of billing dates before the segment,
+// so that billing periods are numbered across segments.
+type BillingSegment struct {
+	Anchor  time.Time
+	Until   *time.Time
+	Billing BillingInterval
+	Offset  int
+}
+
+// Segments splits the billing of s at its plan changes: Billing applies
+// from BillingAnchor on, and every plan change starts a new billing cycle
+// on its effective date. Changes taking effect during the trial start at
+// its end instead, the latest of them winning.
+func (s *Subscription) Segments() []BillingSegment {
+	anchor := s.BillingAnchor()
+	segs := []BillingSegment{{Anchor: anchor, Billing: s.Billing}}
+
+	for _, pc := range s.PlanChanges {
+		from := pc.EffectiveFrom
+		if from.Before(anchor) {
+			from = anchor
+		}
+
+		last := &segs[len(segs)-1]
+		if from.Equal(last.Anchor) {
+			last.Billing = pc.Billing
+			continue
+		}
+
+		until := from.AddDate(0, 0, -1)
+		last.Until = &until
+		segs = append(segs, BillingSegment{
+			Anchor:  from,
+			Billing: pc.Billing,
+			Offset:  last.Offset + last.periods(),
+		})
+	}
+
+	return segs
+}
+
+// periods counts the billing dates of a closed segment.
+func (b BillingSegment) periods() int {
+	n := 0
+	for !b.Billing.Nth(b.Anchor, n).After(*b.Until) {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,115 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSegments(t *testing.T) {
+	day := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	until := func(y int, m time.Month, d int) *time.Time { t := day(y, m, d); return &t }
+	monthly := BillingInterval{Unit: BillingMonth, Count: 1}
+	quarterly := BillingInterval{Unit: BillingMonth, Count: 3}
+	yearly := BillingInterval{Unit: BillingYear, Count: 1}
+	change := func(from time.Time, b BillingInterval) PlanChange {
+		return PlanChange{EffectiveFrom: from, Price: 100, Billing: b}
+	}
+
+	tests := []struct {
+		name string
+		sub  Subscription
+		want []BillingSegment
+	}{
+		{
+			name: "no plan changes",
+			sub:  Subscription{Billing: monthly, StartDate: day(2024, time.January, 31)},
+			want: []BillingSegment{{Anchor: day(2024, time.January, 31), Billing: monthly}},
+		},
+		{
+			name: "change starts a new cycle",
+			sub: Subscription{
+				Billing: monthly, StartDate: day(2024, time.January, 31),
+				PlanChanges: []PlanChange{change(day(2024, time.April, 20), yearly)},
+			},
+			// Billed Jan 31, Feb 29 and Mar 31 before the change.
+			want: []BillingSegment{
+				{Anchor: day(2024, time.January, 31), Until: until(2024, time.April, 19), Billing: monthly},
+				{Anchor: day(2024, time.April, 20), Billing: yearly, Offset: 3},
+			},
+		},
+		{
+			name: "offsets add up",
+			sub: Subscription{
+				Billing: monthly, StartDate: day(2024, time.January, 1),
+				PlanChanges: []PlanChange{
+					change(day(2024, time.February, 15), quarterly),
+					change(day(2024, time.December, 1), monthly),
+				},
+			},
+			// Two monthly periods, then quarterly ones on Feb 15, May 15,
+			// Aug 15 and Nov 15.
+			want: []BillingSegment{
+				{Anchor: day(2024, time.January, 1), Until: until(2024, time.February, 14), Billing: monthly},
+				{Anchor: day(2024, time.February, 15), Until: until(2024, time.November, 30), Billing: quarterly, Offset: 2},
+				{Anchor: day(2024, time.December, 1), Billing: monthly, Offset: 6},
+			},
+		},
+		{
+			name: "changes during the trial start at its end",
+			sub: Subscription{
+				Billing: monthly, StartDate: day(2024, time.January, 15), TrialEndDate: until(2024, time.February, 14),
+				PlanChanges: []PlanChange{
+					change(day(2024, time.January, 20), quarterly),
+					change(day(2024, time.February, 1), yearly),
+				},
+			},
+			want: []BillingSegment{{Anchor: day(2024, time.February, 15), Billing: yearly}},
+		},
+		{
+			name: "change on the anchor replaces the interval",
+			sub: Subscription{
+				Billing: monthly, StartDate: day(2024, time.January, 15), TrialEndDate: until(2024, time.January, 31),
+				PlanChanges: []PlanChange{change(day(2024, time.February, 1), quarterly)},
+			},
+			want: []BillingSegment{{Anchor: day(2024, time.February, 1), Billing: quarterly}},
+		},
+	}
+
+	for _, tt := range tests {
+		got := tt.sub.Segments()
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %d segments %+v, want %d", tt.name, len(got), got, len(tt.want))
+			continue
+		}
+		for i, w := range tt.want {
+			g := got[i]
+			if !g.Anchor.Equal(w.Anchor) || g.Billing != w.Billing || g.Offset != w.Offset ||
+				(g.Until == nil) != (w.Until == nil) || (g.Until != nil && !g.Until.Equal(*w.Until)) {
+				t.Errorf("%s: segment %d is %+v, want %+v", tt.name, i, g, w)
+			}
+		}
+	}
+}
+
+func TestPlanOn(t *testing.T) {
+	day := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+	first, second := uuid.New(), uuid.New()
+
+	sub := Subscription{
+		PlanID:      &first,
+		StartDate:   day(time.January, 1),
+		PlanChanges: []PlanChange{{EffectiveFrom: day(time.March, 1), PlanID: &second}},
+	}
+
+	if got := sub.PlanOn(day(time.February, 29)); got == nil || *got != first {
+		t.Errorf("before the change: got %v, want %v", got, first)
+	}
+	if got := sub.PlanOn(day(time.March, 1)); got == nil || *got != second {
+		t.Errorf("on the change: got %v, want %v", got, second)
+	}
+	if got := (&Subscription{}).PlanOn(day(time.March, 1)); got != nil {
+		t.Errorf("without plans: got %v, want nil", got)
+	}
+}
@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Service is an entry of the service catalog: the canonical name of a
+// service and the other names it is known by.
+type Service struct {
+	ID       uuid.UUID
+	Name     string
+	Category string
+	Aliases  []string
+
+	// DefaultPrice, when set, is charged by subscriptions to the service
+	// that give no price of their own, in DefaultCurrency every
+	// DefaultBilling.
+	DefaultPrice    *int
+	DefaultCurrency string
+	DefaultBilling  BillingInterval
+
+	// Plans are the tiers of the service ordered by name.
+	Plans []Plan
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// ServiceKey is what service names are compared by: case and runs of
+// white space do not matter.
+func ServiceKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), " "))
+}
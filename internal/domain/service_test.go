@@ -0,0 +1,24 @@
+package domain
+
+import "testing"
+
+func TestServiceKey(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Yandex Plus", "yandex plus"},
+		{"yandex plus", "yandex plus"},
+		{"  YANDEX   Plus\t", "yandex plus"},
+		{"Yandex\nPlus", "yandex plus"},
+		{"Кинопоиск", "кинопоиск"},
+		{"YandexPlus", "yandexplus"},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := ServiceKey(tt.name); got != tt.want {
+			t.Errorf("ServiceKey(%q): got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
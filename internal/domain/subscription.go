@@ -10,11 +10,117 @@ type Subscription struct {
 	ID          uuid.UUID
 	UserID      uuid.UUID
 	ServiceName string
-	Price       int
+	// ServiceID links the subscription to a catalog entry, whose name
+	// ServiceName then is; unknown services only have ServiceName.
+	ServiceID *uuid.UUID
+	// PlanID is the plan of the service the subscription started on, if
+	// any; Price and Billing are then those of the plan.
+	PlanID   *uuid.UUID
+	Price    int
+	Currency string
+	Billing  BillingInterval
 
 	StartDate time.Time
 	EndDate   *time.Time
+	// TrialEndDate is the last day of a free trial; billing is anchored on
+	// the day after it.
+	TrialEndDate *time.Time
 
+	// PriceChanges are the later prices of the subscription ordered by
+	// EffectiveFrom; Price applies before the first of them.
+	PriceChanges []PriceChange
+	// PlanChanges are the later plans of the subscription ordered by
+	// EffectiveFrom; see Segments and PriceOn.
+	PlanChanges []PlanChange
+	// Pauses are the periods the subscription is not billed in, ordered
+	// and not overlapping.
+	Pauses []Pause
+	// Discounts lower the charges they cover; see DiscountOn.
+	Discounts []Discount
+
+	// Version increases with every change; writes that carry a non-zero
+	// Version only apply while it is still current.
+	Version   int
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	// DeletedAt is set while the subscription is in the trash.
+	DeletedAt *time.Time
+}
+
+// BillingAnchor is the first billing date: the day after the trial, or
+// StartDate without one.
+func (s *Subscription) BillingAnchor() time.Time {
+	if s.TrialEndDate != nil {
+		return s.TrialEndDate.AddDate(0, 0, 1)
+	}
+	return s.StartDate
+}
+
+// PriceChange sets the price charged from EffectiveFrom on.
+type PriceChange struct {
+	EffectiveFrom time.Time
+	Price         int
+}
+
+// PriceOn returns the price in effect on day: that of the latest price or
+// plan change effective by then, a price change winning over a plan change
+// on the same day.
+func (s *Subscription) PriceOn(day time.Time) int {
+	price, since := s.Price, time.Time{}
+	for _, pc := range s.PlanChanges {
+		if pc.EffectiveFrom.After(day) {
+			break
+		}
+		price, since = pc.Price, pc.EffectiveFrom
+	}
+	for _, pc := range s.PriceChanges {
+		if pc.EffectiveFrom.After(day) {
+			break
+		}
+		if !pc.EffectiveFrom.Before(since) {
+			price = pc.Price
+		}
+	}
+	return price
+}
+
+// Pause suspends billing from StartDate to EndDate inclusive, or until
+// further notice when EndDate is nil.
+type Pause struct {
+	StartDate time.Time
+	EndDate   *time.Time
+}
+
+// Covers reports whether day falls inside p.
+func (p Pause) Covers(day time.Time) bool {
+	return !day.Before(p.StartDate) && (p.EndDate == nil || !day.After(*p.EndDate))
+}
+
+// PausedOn reports whether billing is paused on day.
+func (s *Subscription) PausedOn(day time.Time) bool {
+	for _, p := range s.Pauses {
+		if p.Covers(day) {
+			return true
+		}
+	}
+	return false
+}
+
+// Status is where a subscription stands relative to a given day.
+type Status string
+
+const (
+	StatusActive   Status = "active"
+	StatusPaused   Status = "paused"
+	StatusEnded    Status = "ended"
+	StatusUpcoming Status = "upcoming"
+)
+
+func (s Status) Valid() bool {
+	switch s {
+	case StatusActive, StatusPaused, StatusEnded, StatusUpcoming:
+		return true
+	default:
+		return false
+	}
 }
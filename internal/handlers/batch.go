@@ -0,0 +1,185 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/httpserver"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+// Batch applies several writes at once
+// @Summary      Batch create, update and delete subscriptions
+// @Description  Applies up to 100 operations. In atomic mode (default) they run in one transaction:
+// @Description  if any fails nothing is applied and the others report 424. In best_effort mode each
+// @Description  operation is applied on its own. The response always lists one result per operation.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        Idempotency-Key header string false "Unique key of this batch; retries with the same key and body replay the first response"
+// @Param        batch body BatchRequest true "Operations"
+// @Success      200 {object} BatchResponse
+// @Failure      400 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/batch [post]
+func (h *SubscriptionHandler) Batch(c *gin.Context) {
+	var req BatchRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	mode := service.BatchMode(req.Mode)
+	if mode == "" {
+		mode = service.BatchAtomic
+	}
+	if !mode.Valid() {
+		badRequest(c, "invalid mode")
+		return
+	}
+
+	if n := len(req.Operations); n == 0 || n > service.MaxBatchSize {
+		handleError(c, domain.NewValidationError(domain.FieldError{
+			Field:   "operations",
+			Code:    "invalid_length",
+			Message: fmt.Sprintf("must hold between 1 and %d operations", service.MaxBatchSize),
+		}))
+		return
+	}
+
+	// Operations that cannot even be decoded fail before the service sees
+	// the rest.
+	var (
+		ops     []service.BatchOperation
+		indexes []int
+		results = make([]service.BatchResult, len(req.Operations))
+	)
+	for i, r := range req.Operations {
+		op, err := toBatchOperation(r)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		ops = append(ops, op)
+		indexes = append(indexes, i)
+	}
+
+	if len(ops) == len(req.Operations) || (mode == service.BatchBestEffort && len(ops) > 0) {
+		res, err := h.svc.Batch(c.Request.Context(), mode, ops)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+		for j, i := range indexes {
+			results[i] = res[j]
+		}
+	} else if mode == service.BatchAtomic {
+		for i := range results {
+			if results[i].Err == nil {
+				results[i].Err = service.ErrBatchAborted
+			}
+		}
+	}
+
+	resp := BatchResponse{
+		Mode:    string(mode),
+		Results: make([]BatchItemResponse, 0, len(results)),
+	}
+	for i, r := range results {
+		item := BatchItemResponse{Index: i, Op: req.Operations[i].Op}
+
+		switch {
+		case r.Err != nil:
+			p := problemFor(r.Err)
+			if p.status == http.StatusInternalServerError {
+				slog.ErrorContext(c.Request.Context(), "batch operation failed",
+					"err", r.Err,
+					"index", i,
+					"request_id", httpserver.GetRequestID(c),
+				)
+			}
+			item.Status = p.status
+			item.Error = &Problem{
+				Type:   p.typ,
+				Title:  http.StatusText(p.status),
+				Status: p.status,
+				Detail: p.detail,
+			}
+			if len(p.fields) > 0 {
+				item.Error.Errors = toFieldErrors(p.fields)
+			}
+			resp.Failed++
+		case r.Subscription != nil:
+			sr := toResponse(r.Subscription)
+			item.Subscription = &sr
+			item.Status = http.StatusOK
+			if item.Op == string(service.BatchCreate) {
+				item.Status = http.StatusCreated
+			}
+			resp.Succeeded++
+		default:
+			item.Status = http.StatusNoContent
+			resp.Succeeded++
+		}
+
+		resp.Results = append(resp.Results, item)
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// toBatchOperation decodes one operation, reporting its fields as a single
+// request of that kind would.
+func toBatchOperation(r BatchOperationRequest) (service.BatchOperation, error) {
+	op := service.BatchOperation{Op: service.BatchOp(r.Op)}
+	if !op.Op.Valid() {
+		return op, domain.NewValidationError(domain.FieldError{
+			Field: "op", Code: "invalid_operation", Message: "must be one of create, update, delete",
+		})
+	}
+
+	if r.ID != nil {
+		op.Subscription.ID = *r.ID
+	}
+
+	version, ok := parseETag(r.IfMatch)
+	if !ok {
+		return op, domain.NewValidationError(domain.FieldError{
+			Field: "if_match", Code: "invalid_format", Message: "must be an ETag",
+		})
+	}
+	op.Subscription.Version = version
+
+	if op.Op == service.BatchDelete {
+		return op, nil
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(r.Subscription, &raw); err != nil || raw == nil {
+		return op, domain.NewValidationError(domain.FieldError{
+			Field: "subscription", Code: "required", Message: "must be an object",
+		})
+	}
+
+	if op.Op == service.BatchCreate {
+		var req CreateSubscriptionRequest
+		if err := decodeFields(raw, &req); err != nil {
+			return op, err
+		}
+		op.Subscription = fromCreateRequest(req)
+		return op, nil
+	}
+
+	var req UpdateSubscriptionRequest
+	if err := decodeFields(raw, &req); err != nil {
+		return op, err
+	}
+	applyUpdate(req, &op.Subscription)
+	return op, nil
+}
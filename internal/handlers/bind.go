@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+var errMalformedBody = errors.New("malformed request body")
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// bindJSON decodes the request body into dst field by field, so that every
+// field with a wrong type or format is reported instead of only the first.
+// dst must be a pointer to a struct with json tags.
+func bindJSON(c *gin.Context, dst any) error {
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request.Body).Decode(&raw); err != nil {
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("%w: empty body", errMalformedBody)
+		}
+		return fmt.Errorf("%w: %w", errMalformedBody, err)
+	}
+
+	return decodeFields(raw, dst)
+}
+
+// decodeFields fills dst from already split JSON members; see bindJSON.
+func decodeFields(raw map[string]json.RawMessage, dst any) error {
+	verr := domain.NewValidationError()
+
+	v := reflect.ValueOf(dst).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+
+		msg, ok := raw[name]
+		if !ok {
+			continue
+		}
+
+		field := v.Field(i)
+		if err := json.Unmarshal(msg, field.Addr().Interface()); err != nil {
+			verr.Add(name, "invalid_format", "must be "+describeType(field.Type()))
+		}
+	}
+
+	return verr.Err()
+}
+
+func describeType(t reflect.Type) string {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return "an RFC 3339 timestamp"
+	case t == uuidType:
+		return "a UUID"
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "an integer"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	default:
+		return "a valid " + t.String()
+	}
+}
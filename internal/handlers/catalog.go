@@ -0,0 +1,362 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+type CatalogHandler struct {
+	svc service.CatalogService
+}
+
+func NewCatalogHandler(svc service.CatalogService) *CatalogHandler {
+	return &CatalogHandler{svc: svc}
+}
+
+// List lists catalog services
+// @Summary      List services
+// @Description  List the service catalog by name, optionally of one category or searched by name or alias
+// @Tags         services
+// @Produce      json
+// @Param        category query string false "Category"
+// @Param        q query string false "Name or alias prefix, ignoring case"
+// @Success      200 {array} ServiceResponse
+// @Failure      500 {object} Problem
+// @Router       /services [get]
+func (h *CatalogHandler) List(c *gin.Context) {
+	var f service.CatalogFilter
+
+	if v := c.Query("category"); v != "" {
+		f.Category = &v
+	}
+
+	if v := c.Query("q"); v != "" {
+		f.Search = &v
+	}
+
+	services, err := h.svc.List(c.Request.Context(), f)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	resp := make([]ServiceResponse, 0, len(services))
+	for i := range services {
+		resp = append(resp, toServiceResponse(&services[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Create adds a service to the catalog
+// @Summary      Create service
+// @Description  Add a service under its canonical name. Subscriptions naming it by that name or an alias,
+// @Description  ignoring case, are linked to it and take its defaults for the fields they omit.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        service body ServiceRequest true "Service"
+// @Success      201 {object} ServiceResponse
+// @Failure      400 {object} Problem
+// @Failure      409 {object} Problem "Name or alias already taken"
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /services [post]
+func (h *CatalogHandler) Create(c *gin.Context) {
+	var req ServiceRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	svc := fromServiceRequest(req)
+
+	if err := h.svc.Create(c.Request.Context(), &svc); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toServiceResponse(&svc))
+}
+
+// GetByID gets a catalog service
+// @Summary      Get service
+// @Tags         services
+// @Produce      json
+// @Param        id path string true "Service ID"
+// @Success      200 {object} ServiceResponse
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /services/{id} [get]
+func (h *CatalogHandler) GetByID(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	svc, err := h.svc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toServiceResponse(svc))
+}
+
+// Update replaces a catalog service
+// @Summary      Update service
+// @Description  Replace a service and its aliases. A new name is carried over to its subscriptions.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Service ID"
+// @Param        service body ServiceRequest true "Service"
+// @Success      200 {object} ServiceResponse
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Name or alias already taken"
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /services/{id} [put]
+func (h *CatalogHandler) Update(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	var req ServiceRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	svc := fromServiceRequest(req)
+	svc.ID = id
+
+	if err := h.svc.Update(c.Request.Context(), &svc); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toServiceResponse(&svc))
+}
+
+// Delete removes a service from the catalog
+// @Summary      Delete service
+// @Description  Remove a service no subscription outside the trash refers to; those in the trash are unlinked from it
+// @Tags         services
+// @Param        id path string true "Service ID"
+// @Success      204
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Service still referenced"
+// @Failure      500 {object} Problem
+// @Router       /services/{id} [delete]
+func (h *CatalogHandler) Delete(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// CreatePlan adds a plan to a catalog service
+// @Summary      Create plan
+// @Description  Add a plan (tier) with its price and billing interval to a service. Subscriptions can start
+// @Description  on it or change to it; editing it later only affects subscriptions put on it afterwards.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Service ID"
+// @Param        plan body PlanRequest true "Plan"
+// @Success      201 {object} PlanResponse
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Plan name already taken"
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /services/{id}/plans [post]
+func (h *CatalogHandler) CreatePlan(c *gin.Context) {
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	var req PlanRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	plan := fromPlanRequest(req)
+	plan.ServiceID = serviceID
+
+	if err := h.svc.CreatePlan(c.Request.Context(), &plan); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, toPlanResponse(&plan))
+}
+
+// UpdatePlan replaces a plan of a catalog service
+// @Summary      Update plan
+// @Description  Replace a plan. Subscriptions already on it keep the price and interval they got; its
+// @Description  currency cannot change while subscriptions refer to it.
+// @Tags         services
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Service ID"
+// @Param        plan_id path string true "Plan ID"
+// @Param        plan body PlanRequest true "Plan"
+// @Success      200 {object} PlanResponse
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Plan name already taken, or currency of a plan in use changed"
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /services/{id}/plans/{plan_id} [put]
+func (h *CatalogHandler) UpdatePlan(c *gin.Context) {
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	planID, err := uuid.Parse(c.Param("plan_id"))
+	if err != nil {
+		badRequest(c, "invalid plan_id")
+		return
+	}
+
+	var req PlanRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	plan := fromPlanRequest(req)
+	plan.ID, plan.ServiceID = planID, serviceID
+
+	if err := h.svc.UpdatePlan(c.Request.Context(), &plan); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toPlanResponse(&plan))
+}
+
+// DeletePlan removes a plan of a catalog service
+// @Summary      Delete plan
+// @Description  Remove a plan that no subscription started on or changed to
+// @Tags         services
+// @Param        id path string true "Service ID"
+// @Param        plan_id path string true "Plan ID"
+// @Success      204
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Plan still referenced"
+// @Failure      500 {object} Problem
+// @Router       /services/{id}/plans/{plan_id} [delete]
+func (h *CatalogHandler) DeletePlan(c *gin.Context) {
+	serviceID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	planID, err := uuid.Parse(c.Param("plan_id"))
+	if err != nil {
+		badRequest(c, "invalid plan_id")
+		return
+	}
+
+	if err := h.svc.DeletePlan(c.Request.Context(), serviceID, planID); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func fromServiceRequest(req ServiceRequest) domain.Service {
+	return domain.Service{
+		Name:            req.Name,
+		Category:        req.Category,
+		Aliases:         req.Aliases,
+		DefaultPrice:    req.DefaultPrice,
+		DefaultCurrency: req.DefaultCurrency,
+		DefaultBilling: domain.BillingInterval{
+			Unit:  domain.BillingUnit(req.DefaultBillingUnit),
+			Count: req.DefaultBillingCount,
+		},
+	}
+}
+
+func toServiceResponse(s *domain.Service) ServiceResponse {
+	aliases := s.Aliases
+	if aliases == nil {
+		aliases = []string{}
+	}
+
+	plans := make([]PlanResponse, 0, len(s.Plans))
+	for i := range s.Plans {
+		plans = append(plans, toPlanResponse(&s.Plans[i]))
+	}
+
+	return ServiceResponse{
+		ID:                  s.ID,
+		Name:                s.Name,
+		Category:            s.Category,
+		Aliases:             aliases,
+		DefaultPrice:        s.DefaultPrice,
+		DefaultCurrency:     s.DefaultCurrency,
+		DefaultBillingUnit:  string(s.DefaultBilling.Unit),
+		DefaultBillingCount: s.DefaultBilling.Count,
+		Plans:               plans,
+		CreatedAt:           s.CreatedAt,
+		UpdatedAt:           s.UpdatedAt,
+	}
+}
+
+func fromPlanRequest(req PlanRequest) domain.Plan {
+	return domain.Plan{
+		Name:     req.Name,
+		Price:    req.Price,
+		Currency: req.Currency,
+		Billing: domain.BillingInterval{
+			Unit:  domain.BillingUnit(req.BillingUnit),
+			Count: req.BillingCount,
+		},
+	}
+}
+
+func toPlanResponse(p *domain.Plan) PlanResponse {
+	return PlanResponse{
+		ID:           p.ID,
+		ServiceID:    p.ServiceID,
+		Name:         p.Name,
+		Price:        p.Price,
+		Currency:     p.Currency,
+		BillingUnit:  string(p.Billing.Unit),
+		BillingCount: p.Billing.Count,
+		CreatedAt:    p.CreatedAt,
+		UpdatedAt:    p.UpdatedAt,
+	}
+}
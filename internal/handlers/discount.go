@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// AddDiscount attaches a discount to a subscription
+// @Summary      Add discount
+// @Description  Take a percentage or a fixed amount off every charge from start_date to end_date,
+// @Description  or off the first periods billing periods (e.g. an introductory price for 3 months).
+// @Description  Discounts covering the same charge add up to at most its price.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        discount body DiscountRequest true "Discount"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/discounts [post]
+func (h *SubscriptionHandler) AddDiscount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req DiscountRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sub, err := h.svc.AddDiscount(c.Request.Context(), id, version, domain.Discount{
+		Kind:      domain.DiscountKind(req.Kind),
+		Value:     req.Value,
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+		Periods:   req.Periods,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+// RemoveDiscount detaches a discount from a subscription
+// @Summary      Remove discount
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        discount_id path int true "Discount ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/discounts/{discount_id} [delete]
+func (h *SubscriptionHandler) RemoveDiscount(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	discountID, err := strconv.ParseInt(c.Param("discount_id"), 10, 64)
+	if err != nil {
+		badRequest(c, "invalid discount_id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.svc.RemoveDiscount(c.Request.Context(), id, version, discountID)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+func toDiscountResponses(discounts []domain.Discount) []DiscountResponse {
+	if len(discounts) == 0 {
+		return nil
+	}
+
+	res := make([]DiscountResponse, 0, len(discounts))
+	for _, d := range discounts {
+		res = append(res, DiscountResponse{
+			ID:        d.ID,
+			Kind:      string(d.Kind),
+			Value:     d.Value,
+			StartDate: d.StartDate,
+			EndDate:   d.EndDate,
+			Periods:   d.Periods,
+		})
+	}
+	return res
+}
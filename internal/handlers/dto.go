@@ -1,27 +1,57 @@
 package handlers
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
 )
 
-
 // @name CreateSubscriptionRequest
 type CreateSubscriptionRequest struct {
+	// ServiceName is canonicalized, and the subscription linked, when it
+	// names a catalog service; ServiceID links one explicitly instead.
 	ServiceName string     `json:"service_name"`
-	Price       int        `json:"price"`
-	UserID      uuid.UUID  `json:"user_id"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	// PlanID starts the subscription on a plan of the service, which it
+	// is then linked to.
+	PlanID *uuid.UUID `json:"plan_id,omitempty"`
+	// Price and the fields below it default to those of the plan, or of
+	// the catalog service, when omitted.
+	Price        int        `json:"price"`
+	Currency     string     `json:"currency,omitempty" example:"RUB"`
+	UserID       uuid.UUID  `json:"user_id"`
+	BillingUnit  string     `json:"billing_unit,omitempty" enums:"week,month,year" example:"month"`
+	BillingCount int        `json:"billing_count,omitempty" example:"3"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	// TrialEndDate is the last day of a free trial; billing starts the day
+	// after. TrialDays gives the trial as a length instead and wins over it.
+	TrialEndDate *time.Time `json:"trial_end_date,omitempty"`
+	TrialDays    int        `json:"trial_days,omitempty" example:"14"`
 }
 
 // @name UpdateSubscriptionRequest
 type UpdateSubscriptionRequest struct {
+	// ServiceName is canonicalized, and the subscription linked, when it
+	// names a catalog service; ServiceID links one explicitly instead.
 	ServiceName string     `json:"service_name"`
-	Price       int        `json:"price"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	// PlanID starts the subscription on a plan of the service, which it
+	// is then linked to.
+	PlanID *uuid.UUID `json:"plan_id,omitempty"`
+	// Price and the fields below it default to those of the plan, or of
+	// the catalog service, when omitted.
+	Price        int        `json:"price"`
+	Currency     string     `json:"currency,omitempty" example:"RUB"`
+	BillingUnit  string     `json:"billing_unit,omitempty" enums:"week,month,year" example:"month"`
+	BillingCount int        `json:"billing_count,omitempty" example:"3"`
+	StartDate    time.Time  `json:"start_date"`
+	EndDate      *time.Time `json:"end_date,omitempty"`
+	// TrialEndDate is the last day of a free trial; billing starts the day
+	// after. TrialDays gives the trial as a length instead and wins over it.
+	TrialEndDate *time.Time `json:"trial_end_date,omitempty"`
+	TrialDays    int        `json:"trial_days,omitempty" example:"14"`
 }
 
 // @name SubscriptionResponse
@@ -29,14 +59,336 @@ type SubscriptionResponse struct {
 	ID          uuid.UUID  `json:"id"`
 	UserID      uuid.UUID  `json:"user_id"`
 	ServiceName string     `json:"service_name"`
+	ServiceID   *uuid.UUID `json:"service_id,omitempty"`
+	// PlanID is the plan the subscription started on; see plan_changes.
+	PlanID *uuid.UUID `json:"plan_id,omitempty"`
+	// CurrentPlanID is the plan the subscription is on today.
+	CurrentPlanID *uuid.UUID `json:"current_plan_id,omitempty"`
+	Price         int        `json:"price"`
+	Currency      string     `json:"currency"`
+	BillingUnit   string     `json:"billing_unit"`
+	BillingCount  int        `json:"billing_count"`
+	StartDate     time.Time  `json:"start_date"`
+	EndDate       *time.Time `json:"end_date,omitempty"`
+	TrialEndDate  *time.Time `json:"trial_end_date,omitempty"`
+	// PriceChanges are the later prices; price applies before the first.
+	PriceChanges []PriceChangeResponse `json:"price_changes,omitempty"`
+	// PlanChanges are the later plans; price, billing_unit and
+	// billing_count apply before the first.
+	PlanChanges []PlanChangeResponse `json:"plan_changes,omitempty"`
+	Pauses      []PauseResponse      `json:"pauses,omitempty"`
+	Discounts   []DiscountResponse   `json:"discounts,omitempty"`
+	ETag        string               `json:"etag"`
+	CreatedAt   time.Time            `json:"created_at"`
+	UpdatedAt   time.Time            `json:"updated_at"`
+	DeletedAt   *time.Time           `json:"deleted_at,omitempty"`
+}
+
+// @name SubscriptionListResponse
+type SubscriptionListResponse struct {
+	Items      []SubscriptionResponse `json:"items"`
+	TotalCount int                    `json:"total_count"`
+	Limit      int                    `json:"limit"`
+	// Offset is omitted when paging by cursor.
+	Offset *int `json:"offset,omitempty"`
+	// NextCursor fetches the following page; omitted on the last one.
+	NextCursor string    `json:"next_cursor,omitempty"`
+	Links      PageLinks `json:"links"`
+}
+
+// @name PageLinks
+type PageLinks struct {
+	Self string `json:"self"`
+	Next string `json:"next,omitempty"`
+	Prev string `json:"prev,omitempty"`
+}
+
+// @name PriceChangeRequest
+type PriceChangeRequest struct {
+	Price int `json:"price" example:"499"`
+	// EffectiveFrom is the first day charged the new price; it must be
+	// after start_date and may lie in the future.
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// @name PriceChangeResponse
+type PriceChangeResponse struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	Price         int       `json:"price"`
+}
+
+// @name PricePeriodResponse
+type PricePeriodResponse struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	// EffectiveTo is the last day of the period; omitted for an open end.
+	EffectiveTo *time.Time `json:"effective_to,omitempty"`
 	Price       int        `json:"price"`
-	StartDate   time.Time  `json:"start_date"`
-	EndDate     *time.Time `json:"end_date,omitempty"`
-	CreatedAt   time.Time  `json:"created_at"`
-	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// @name PriceTimelineResponse
+type PriceTimelineResponse struct {
+	SubscriptionID uuid.UUID             `json:"subscription_id"`
+	Currency       string                `json:"currency"`
+	Periods        []PricePeriodResponse `json:"periods"`
+	ETag           string                `json:"etag"`
+}
+
+// @name PlanChangeRequest
+type PlanChangeRequest struct {
+	PlanID uuid.UUID `json:"plan_id"`
+	// EffectiveFrom is the first day of the new plan, which starts a new
+	// billing cycle; it must be after start_date and may lie in the future.
+	EffectiveFrom time.Time `json:"effective_from"`
+}
+
+// @name PlanChangeResponse
+type PlanChangeResponse struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	// PlanID is omitted once the plan has been deleted from the catalog.
+	PlanID       *uuid.UUID `json:"plan_id,omitempty"`
+	PlanName     string     `json:"plan_name"`
+	Price        int        `json:"price"`
+	BillingUnit  string     `json:"billing_unit"`
+	BillingCount int        `json:"billing_count"`
+}
+
+// @name PauseRequest
+type PauseRequest struct {
+	StartDate time.Time `json:"start_date"`
+	// EndDate is the last paused day; omit it to pause until resumed.
+	EndDate *time.Time `json:"end_date,omitempty"`
+}
+
+// @name ResumeRequest
+type ResumeRequest struct {
+	// Date is the first billed day again; defaults to today.
+	Date *time.Time `json:"date,omitempty"`
+}
+
+// @name PauseResponse
+type PauseResponse struct {
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+// @name DiscountRequest
+type DiscountRequest struct {
+	// Kind is percent (value percent off) or fixed (value off, in the
+	// subscription currency), per charge.
+	Kind  string `json:"kind" enums:"percent,fixed" example:"percent"`
+	Value int    `json:"value" example:"20"`
+	// The discount covers charges from start_date to end_date (open-ended
+	// without it), or the first periods billing periods.
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Periods   int        `json:"periods,omitempty" example:"3"`
+}
+
+// @name DiscountResponse
+type DiscountResponse struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Value     int        `json:"value"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Periods   int        `json:"periods,omitempty"`
+}
+
+// @name TrialEndingResponse
+type TrialEndingResponse struct {
+	Subscription SubscriptionResponse `json:"subscription"`
+	// DaysLeft counts the trial days left after today.
+	DaysLeft         int       `json:"days_left"`
+	FirstChargeDate  time.Time `json:"first_charge_date"`
+	FirstChargePrice int       `json:"first_charge_price"`
+}
+
+// @name TrialsEndingResponse
+type TrialsEndingResponse struct {
+	Days  int                   `json:"days"`
+	Items []TrialEndingResponse `json:"items"`
+}
+
+// @name SubscriptionEventResponse
+type SubscriptionEventResponse struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type" enums:"created,updated,deleted,restored"`
+	// Before is omitted for created, After for deleted; before a restore
+	// it is the subscription as it lay in the trash.
+	Before     *SubscriptionResponse `json:"before,omitempty"`
+	After      *SubscriptionResponse `json:"after,omitempty"`
+	Actor      string                `json:"actor,omitempty"`
+	RequestID  string                `json:"request_id,omitempty"`
+	OccurredAt time.Time             `json:"occurred_at"`
+}
+
+// @name SubscriptionHistoryResponse
+type SubscriptionHistoryResponse struct {
+	SubscriptionID uuid.UUID                   `json:"subscription_id"`
+	Events         []SubscriptionEventResponse `json:"events"`
+}
+
+// @name BatchRequest
+type BatchRequest struct {
+	// Mode is atomic (default): all operations or none are applied, or
+	// best_effort: each operation is applied on its own.
+	Mode       string                  `json:"mode,omitempty" enums:"atomic,best_effort" example:"atomic"`
+	Operations []BatchOperationRequest `json:"operations"`
+}
+
+// @name BatchOperationRequest
+type BatchOperationRequest struct {
+	Op string `json:"op" enums:"create,update,delete"`
+	// ID addresses update and delete.
+	ID *uuid.UUID `json:"id,omitempty"`
+	// IfMatch is the ETag an update or delete is based on.
+	IfMatch string `json:"if_match,omitempty"`
+	// Subscription is a CreateSubscriptionRequest for create and an
+	// UpdateSubscriptionRequest for update.
+	Subscription json.RawMessage `json:"subscription,omitempty" swaggertype:"object"`
+}
+
+// @name BatchItemResponse
+type BatchItemResponse struct {
+	Index int    `json:"index"`
+	Op    string `json:"op"`
+	// Status is the HTTP status the operation would have had on its own;
+	// 424 marks operations of a failed atomic batch.
+	Status       int                   `json:"status" example:"201"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Error        *Problem              `json:"error,omitempty"`
+}
+
+// @name BatchResponse
+type BatchResponse struct {
+	Mode      string              `json:"mode"`
+	Succeeded int                 `json:"succeeded"`
+	Failed    int                 `json:"failed"`
+	Results   []BatchItemResponse `json:"results"`
+}
+
+// @name FieldErrorResponse
+type FieldErrorResponse struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// @name MoneyResponse
+type MoneyResponse struct {
+	// Amount is net of discounts: gross less discount.
+	Amount   int    `json:"amount"`
+	Gross    int    `json:"gross"`
+	Discount int    `json:"discount"`
+	Currency string `json:"currency"`
 }
 
 // @name TotalResponse
 type TotalResponse struct {
-	Total int `json:"total"`
+	// Total is net of discounts: gross less discount.
+	Total     int             `json:"total"`
+	Gross     int             `json:"gross"`
+	Discount  int             `json:"discount"`
+	Currency  string          `json:"currency,omitempty"`
+	Subtotals []MoneyResponse `json:"subtotals"`
+}
+
+// @name BreakdownGroupResponse
+type BreakdownGroupResponse struct {
+	ServiceName *string    `json:"service_name,omitempty"`
+	UserID      *uuid.UUID `json:"user_id,omitempty"`
+	Month       string     `json:"month,omitempty" example:"2024-03"`
+
+	TotalResponse
+}
+
+// @name BreakdownResponse
+type BreakdownResponse struct {
+	GroupBy    []string                 `json:"group_by"`
+	Groups     []BreakdownGroupResponse `json:"groups"`
+	GrandTotal TotalResponse            `json:"grand_total"`
+}
+
+// @name ExchangeRateRequest
+type ExchangeRateRequest struct {
+	Rate float64 `json:"rate" example:"92.5"`
+	// Date the rate becomes effective, YYYY-MM-DD or YYYY-MM; defaults to today.
+	Date string `json:"date,omitempty" example:"2024-03-01"`
+}
+
+// @name ExchangeRateResponse
+type ExchangeRateResponse struct {
+	Base      string    `json:"base" example:"USD"`
+	Quote     string    `json:"quote" example:"RUB"`
+	Date      string    `json:"date" example:"2024-03-01"`
+	Rate      float64   `json:"rate" example:"92.5"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// @name ExchangeRateImportItem
+type ExchangeRateImportItem struct {
+	Date  string  `json:"date" example:"2024-03"`
+	Base  string  `json:"base" example:"USD"`
+	Quote string  `json:"quote" example:"RUB"`
+	Rate  float64 `json:"rate" example:"92.5"`
+}
+
+// @name ImportExchangeRatesRequest
+type ImportExchangeRatesRequest struct {
+	Rates []ExchangeRateImportItem `json:"rates"`
+}
+
+// @name ImportExchangeRatesResponse
+type ImportExchangeRatesResponse struct {
+	Imported int `json:"imported"`
+}
+
+// @name ServiceRequest
+type ServiceRequest struct {
+	Name     string   `json:"name" example:"Netflix"`
+	Category string   `json:"category,omitempty" example:"streaming"`
+	Aliases  []string `json:"aliases,omitempty" example:"netflix.com,Нетфликс"`
+	// DefaultPrice is what subscriptions omitting a price are charged.
+	DefaultPrice        *int   `json:"default_price,omitempty" example:"799"`
+	DefaultCurrency     string `json:"default_currency,omitempty" example:"RUB"`
+	DefaultBillingUnit  string `json:"default_billing_unit,omitempty" enums:"week,month,year" example:"month"`
+	DefaultBillingCount int    `json:"default_billing_count,omitempty" example:"1"`
+}
+
+// @name ServiceResponse
+type ServiceResponse struct {
+	ID                  uuid.UUID      `json:"id"`
+	Name                string         `json:"name"`
+	Category            string         `json:"category,omitempty"`
+	Aliases             []string       `json:"aliases"`
+	DefaultPrice        *int           `json:"default_price,omitempty"`
+	DefaultCurrency     string         `json:"default_currency,omitempty"`
+	DefaultBillingUnit  string         `json:"default_billing_unit"`
+	DefaultBillingCount int            `json:"default_billing_count"`
+	Plans               []PlanResponse `json:"plans"`
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
+// @name PlanRequest
+type PlanRequest struct {
+	Name  string `json:"name" example:"Premium"`
+	Price int    `json:"price" example:"1199"`
+	// Currency defaults to the default_currency of the service.
+	Currency     string `json:"currency,omitempty" example:"RUB"`
+	BillingUnit  string `json:"billing_unit,omitempty" enums:"week,month,year" example:"month"`
+	BillingCount int    `json:"billing_count,omitempty" example:"1"`
+}
+
+// @name PlanResponse
+type PlanResponse struct {
+	ID           uuid.UUID `json:"id"`
+	ServiceID    uuid.UUID `json:"service_id"`
+	Name         string    `json:"name"`
+	Price        int       `json:"price"`
+	Currency     string    `json:"currency"`
+	BillingUnit  string    `json:"billing_unit"`
+	BillingCount int       `json:"billing_count"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
 }
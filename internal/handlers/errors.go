@@ -2,19 +2,95 @@ package handlers
 
 import (
 	"errors"
+	"fmt"
+	"log/slog"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/httpserver"
 	"github.com/RomaNano/subscriptions-aggregator/internal/service"
 )
 
 func handleError(c *gin.Context, err error) {
+	p := problemFor(err)
+	if p.status == http.StatusInternalServerError {
+		slog.ErrorContext(c.Request.Context(), "request failed",
+			"err", err,
+			"request_id", httpserver.GetRequestID(c),
+		)
+	}
+
+	writeProblem(c, p.status, p.typ, p.detail, p.fields)
+}
+
+// problem is a Problem before it is bound to a request.
+type problem struct {
+	status int
+	typ    string
+	detail string
+	fields []domain.FieldError
+}
+
+// problemFor maps an error of the lower layers to its HTTP problem.
+func problemFor(err error) problem {
+	var (
+		verr     *domain.ValidationError
+		tooLarge *http.MaxBytesError
+	)
+
 	switch {
-	case errors.Is(err, service.ErrInvalidData),
-		errors.Is(err, service.ErrInvalidPeriod):
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.As(err, &tooLarge):
+		return problem{http.StatusRequestEntityTooLarge, problemTooLarge,
+			fmt.Sprintf("request body must not exceed %d bytes", tooLarge.Limit), nil}
+
+	case errors.Is(err, errMalformedBody),
+		errors.Is(err, service.ErrInvalidPeriod),
+		errors.Is(err, service.ErrInvalidCursor):
+		return problem{http.StatusBadRequest, problemBadRequest, err.Error(), nil}
+
+	case errors.Is(err, domain.ErrNotFound):
+		return problem{http.StatusNotFound, problemNotFound, "resource not found", nil}
+
+	case errors.Is(err, domain.ErrConflict):
+		return problem{http.StatusConflict, problemConflict, err.Error(), nil}
+
+	case errors.Is(err, domain.ErrPreconditionFailed):
+		return problem{http.StatusPreconditionFailed, problemPrecondition,
+			"subscription was modified; fetch it again for the current ETag", nil}
+
+	case errors.Is(err, service.ErrIdempotencyInProgress):
+		return problem{http.StatusConflict, problemConflict, err.Error(), nil}
+
+	case errors.Is(err, service.ErrIdempotencyKeyReused):
+		return problem{http.StatusUnprocessableEntity, problemKeyReused, err.Error(), nil}
+
+	case errors.Is(err, service.ErrBatchAborted):
+		return problem{http.StatusFailedDependency, problemBatchAborted, err.Error(), nil}
+
+	case errors.Is(err, service.ErrNoExchangeRate):
+		return problem{http.StatusUnprocessableEntity, problemNoExchangeRate, err.Error(), nil}
+
+	case errors.As(err, &verr):
+		return problem{http.StatusUnprocessableEntity, problemValidation, "one or more fields are invalid", verr.Fields}
+
+	case errors.Is(err, domain.ErrValidation):
+		return problem{http.StatusUnprocessableEntity, problemValidation, err.Error(), nil}
 
 	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
+		return problem{http.StatusInternalServerError, problemInternal, "", nil}
+	}
+}
+
+func toFieldErrors(fields []domain.FieldError) []FieldErrorResponse {
+	res := make([]FieldErrorResponse, 0, len(fields))
+	for _, f := range fields {
+		res = append(res, FieldErrorResponse{
+			Field:   f.Field,
+			Code:    f.Code,
+			Message: f.Message,
+		})
 	}
+	return res
 }
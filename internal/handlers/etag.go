@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// etag renders a subscription version as a strong entity tag.
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the If-Match header as the version a write is
+// based on: 0 when absent or "*", which skips the check. It answers 400
+// itself when the header holds anything but one strong tag issued by etag.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	v, ok := parseETag(c.GetHeader("If-Match"))
+	if !ok {
+		badRequest(c, "invalid If-Match")
+	}
+	return v, ok
+}
+
+// parseETag is the inverse of etag, also accepting "" and "*" as 0.
+func parseETag(v string) (int, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "*" {
+		return 0, true
+	}
+
+	if len(v) >= 2 && v[0] == '"' && v[len(v)-1] == '"' {
+		if n, err := strconv.Atoi(v[1 : len(v)-1]); err == nil && n > 0 {
+			return n, true
+		}
+	}
+
+	return 0, false
+}
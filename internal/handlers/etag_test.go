@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+func TestParseETag(t *testing.T) {
+	tests := []struct {
+		header string
+		want   int
+		wantOK bool
+	}{
+		{"", 0, true},
+		{"*", 0, true},
+		{" * ", 0, true},
+		{`"7"`, 7, true},
+		{` "7" `, 7, true},
+		{etag(42), 42, true},
+		{"7", 0, false},
+		{`W/"7"`, 0, false},
+		{`"7`, 0, false},
+		{`"`, 0, false},
+		{`""`, 0, false},
+		{`"0"`, 0, false},
+		{`"-1"`, 0, false},
+		{`"abc"`, 0, false},
+		{`"7", "8"`, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := parseETag(tt.header)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("parseETag(%q): got %d %v, want %d %v", tt.header, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// deleteService fails Delete with err, recording the version asked for.
+type deleteService struct {
+	service.SubscriptionService
+	err     error
+	version *int
+}
+
+func (s *deleteService) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	s.version = &version
+	return s.err
+}
+
+func TestIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stale := fmt.Errorf("update subscription: %w", domain.ErrPreconditionFailed)
+
+	tests := []struct {
+		name        string
+		ifMatch     string
+		err         error
+		wantStatus  int
+		wantType    string
+		wantVersion int
+	}{
+		{"no header skips the check", "", nil, http.StatusNoContent, "", 0},
+		{"any version", "*", nil, http.StatusNoContent, "", 0},
+		{"current version", `"3"`, nil, http.StatusNoContent, "", 3},
+		{"stale version", `"2"`, stale, http.StatusPreconditionFailed, problemPrecondition, 2},
+		{"deleted meanwhile", `"3"`, domain.ErrNotFound, http.StatusNotFound, problemNotFound, 3},
+		{"weak tag", `W/"3"`, nil, http.StatusBadRequest, problemBadRequest, -1},
+		{"unquoted", "3", nil, http.StatusBadRequest, problemBadRequest, -1},
+		{"malformed", `"3`, nil, http.StatusBadRequest, problemBadRequest, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &deleteService{err: tt.err}
+			r := gin.New()
+			r.DELETE("/subscriptions/:id", NewSubscriptionHandler(svc).Delete)
+
+			req := httptest.NewRequest(http.MethodDelete, "/subscriptions/"+uuid.NewString(), nil)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantType != "" && !strings.Contains(rec.Body.String(), `"type":"`+tt.wantType+`"`) {
+				t.Errorf("got body %s, want problem type %s", rec.Body, tt.wantType)
+			}
+			switch {
+			case tt.wantVersion < 0 && svc.version != nil:
+				t.Errorf("service called with version %d, want no call", *svc.version)
+			case tt.wantVersion >= 0 && (svc.version == nil || *svc.version != tt.wantVersion):
+				t.Errorf("service called with version %v, want %d", svc.version, tt.wantVersion)
+			}
+		})
+	}
+}
@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+const maxImportBodySize = 8 << 20
+
+var csvImportHeader = []string{"date", "base", "quote", "rate"}
+
+type ExchangeRateHandler struct {
+	svc service.ExchangeRateService
+}
+
+func NewExchangeRateHandler(svc service.ExchangeRateService) *ExchangeRateHandler {
+	return &ExchangeRateHandler{svc: svc}
+}
+
+// List lists exchange rates
+// @Summary      List exchange rates
+// @Description  List stored exchange rates, optionally for one pair and a date range
+// @Tags         rates
+// @Produce      json
+// @Param        base  query string false "Base currency (ISO 4217)"
+// @Param        quote query string false "Quote currency (ISO 4217)"
+// @Param        from  query string false "From date (YYYY-MM-DD)"
+// @Param        to    query string false "To date (YYYY-MM-DD)"
+// @Success      200 {array} ExchangeRateResponse
+// @Failure      400 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /rates [get]
+func (h *ExchangeRateHandler) List(c *gin.Context) {
+	var f service.ExchangeRateFilter
+
+	if v := c.Query("base"); v != "" {
+		v = domain.NormalizeCurrency(v)
+		f.Base = &v
+	}
+
+	if v := c.Query("quote"); v != "" {
+		v = domain.NormalizeCurrency(v)
+		f.Quote = &v
+	}
+
+	if v := c.Query("from"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			badRequest(c, "invalid from")
+			return
+		}
+		f.From = &t
+	}
+
+	if v := c.Query("to"); v != "" {
+		t, err := time.Parse(time.DateOnly, v)
+		if err != nil {
+			badRequest(c, "invalid to")
+			return
+		}
+		f.To = &t
+	}
+
+	rates, err := h.svc.List(c.Request.Context(), f)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	resp := make([]ExchangeRateResponse, 0, len(rates))
+	for i := range rates {
+		resp = append(resp, toExchangeRateResponse(&rates[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// Set creates or replaces an exchange rate
+// @Summary      Set exchange rate
+// @Description  Create or replace the rate for a currency pair (1 base = rate quote) effective from a date
+// @Tags         rates
+// @Accept       json
+// @Produce      json
+// @Param        base  path string true "Base currency (ISO 4217)"
+// @Param        quote path string true "Quote currency (ISO 4217)"
+// @Param        rate body ExchangeRateRequest true "Rate"
+// @Success      200 {object} ExchangeRateResponse
+// @Failure      400 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /rates/{base}/{quote} [put]
+func (h *ExchangeRateHandler) Set(c *gin.Context) {
+	var req ExchangeRateRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	date := time.Now().UTC()
+	if req.Date != "" {
+		d, err := parseRateDate(req.Date)
+		if err != nil {
+			handleError(c, domain.NewValidationError(domain.FieldError{
+				Field:   "date",
+				Code:    "invalid_format",
+				Message: "must be YYYY-MM-DD or YYYY-MM",
+			}))
+			return
+		}
+		date = d
+	}
+
+	r := &domain.ExchangeRate{
+		Base:  c.Param("base"),
+		Quote: c.Param("quote"),
+		Date:  date,
+		Rate:  req.Rate,
+	}
+
+	if err := h.svc.Set(c.Request.Context(), r); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, toExchangeRateResponse(r))
+}
+
+// Import stores a batch of exchange rates
+// @Summary      Import exchange rates
+// @Description  Bulk upload rates as JSON or as CSV with the header "date,base,quote,rate".
+// @Description  Dates are YYYY-MM-DD (daily) or YYYY-MM (monthly). The batch is stored atomically.
+// @Tags         rates
+// @Accept       json
+// @Accept       text/csv
+// @Produce      json
+// @Param        rates body ImportExchangeRatesRequest true "Rates"
+// @Success      200 {object} ImportExchangeRatesResponse
+// @Failure      400 {object} Problem
+// @Failure      413 {object} Problem "Body larger than 8 MiB"
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /rates/import [post]
+func (h *ExchangeRateHandler) Import(c *gin.Context) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxImportBodySize)
+
+	var (
+		items []ExchangeRateImportItem
+		err   error
+	)
+
+	if c.ContentType() == "text/csv" {
+		items, err = readRatesCSV(c.Request.Body)
+	} else {
+		var req ImportExchangeRatesRequest
+		err = bindJSON(c, &req)
+		items = req.Rates
+	}
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	rates := make([]domain.ExchangeRate, len(items))
+	verr := domain.NewValidationError()
+	for i, item := range items {
+		rates[i] = domain.ExchangeRate{
+			Base:  item.Base,
+			Quote: item.Quote,
+			Rate:  item.Rate,
+		}
+		if item.Date == "" {
+			continue
+		}
+
+		date, err := parseRateDate(item.Date)
+		if err != nil {
+			verr.Add(fmt.Sprintf("rates[%d].date", i), "invalid_format", "must be YYYY-MM-DD or YYYY-MM")
+			continue
+		}
+		rates[i].Date = date
+	}
+	if err := verr.Err(); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	if err := h.svc.Import(c.Request.Context(), rates); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, ImportExchangeRatesResponse{Imported: len(rates)})
+}
+
+// Delete deletes exchange rates
+// @Summary      Delete exchange rate
+// @Description  Delete the rate of a pair for one date, or the whole pair history when date is omitted
+// @Tags         rates
+// @Param        base  path string true "Base currency (ISO 4217)"
+// @Param        quote path string true "Quote currency (ISO 4217)"
+// @Param        date  query string false "Effective date (YYYY-MM-DD)"
+// @Success      204
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /rates/{base}/{quote} [delete]
+func (h *ExchangeRateHandler) Delete(c *gin.Context) {
+	var date *time.Time
+	if v := c.Query("date"); v != "" {
+		d, err := parseRateDate(v)
+		if err != nil {
+			badRequest(c, "invalid date")
+			return
+		}
+		date = &d
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), c.Param("base"), c.Param("quote"), date); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// parseRateDate accepts a day (YYYY-MM-DD) or a month (YYYY-MM), the
+// latter meaning the first day of that month.
+func parseRateDate(v string) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01", v)
+}
+
+func readRatesCSV(r io.Reader) ([]ExchangeRateImportItem, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+	cr.FieldsPerRecord = len(csvImportHeader)
+
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body", errMalformedBody)
+		}
+		return nil, fmt.Errorf("%w: %w", errMalformedBody, err)
+	}
+	for i, col := range csvImportHeader {
+		if !strings.EqualFold(strings.TrimSpace(header[i]), col) {
+			return nil, fmt.Errorf("%w: header must be %q", errMalformedBody, strings.Join(csvImportHeader, ","))
+		}
+	}
+
+	var (
+		items []ExchangeRateImportItem
+		verr  = domain.NewValidationError()
+	)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", errMalformedBody, err)
+		}
+
+		rate, err := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
+		if err != nil {
+			verr.Add(fmt.Sprintf("rates[%d].rate", len(items)), "invalid_format", "must be a number")
+		}
+
+		items = append(items, ExchangeRateImportItem{
+			Date:  strings.TrimSpace(rec[0]),
+			Base:  rec[1],
+			Quote: rec[2],
+			Rate:  rate,
+		})
+	}
+
+	return items, verr.Err()
+}
+
+func toExchangeRateResponse(r *domain.ExchangeRate) ExchangeRateResponse {
+	return ExchangeRateResponse{
+		Base:      r.Base,
+		Quote:     r.Quote,
+		Date:      r.Date.Format(time.DateOnly),
+		Rate:      r.Rate,
+		UpdatedAt: r.UpdatedAt,
+	}
+}
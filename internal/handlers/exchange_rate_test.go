@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+// importService counts the rates Import is given.
+type importService struct {
+	service.ExchangeRateService
+	imported int
+}
+
+func (s *importService) Import(ctx context.Context, rates []domain.ExchangeRate) error {
+	s.imported += len(rates)
+	return nil
+}
+
+func TestImportBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	row := "2025-01-01,USD,RUB,90.5\n"
+	csvOf := func(size int) string {
+		var b strings.Builder
+		b.WriteString("date,base,quote,rate\n")
+		for b.Len()+len(row) <= size {
+			b.WriteString(row)
+		}
+		return b.String()
+	}
+	item := `{"date":"2025-01-01","base":"USD","quote":"RUB","rate":90.5},`
+	jsonOf := func(size int) string {
+		var b strings.Builder
+		b.WriteString(`{"rates":[`)
+		for b.Len()+len(item)+2 <= size {
+			b.WriteString(item)
+		}
+		return strings.TrimSuffix(b.String(), ",") + "]}"
+	}
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantStatus  int
+	}{
+		{"CSV at the limit", "text/csv", csvOf(maxImportBodySize), http.StatusOK},
+		{"CSV over the limit", "text/csv", csvOf(maxImportBodySize) + row, http.StatusRequestEntityTooLarge},
+		{"JSON at the limit", "application/json", jsonOf(maxImportBodySize), http.StatusOK},
+		{"JSON over the limit", "application/json", jsonOf(maxImportBodySize + 2*len(item)), http.StatusRequestEntityTooLarge},
+		{"malformed CSV", "text/csv", "date,base\n", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &importService{}
+			r := gin.New()
+			r.POST("/rates/import", NewExchangeRateHandler(svc).Import)
+
+			req := httptest.NewRequest(http.MethodPost, "/rates/import", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %.200s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge {
+				if !strings.Contains(rec.Body.String(), problemTooLarge) || rec.Header().Get("Content-Type") != problemContentType {
+					t.Errorf("got %s %s, want a %s problem", rec.Header().Get("Content-Type"), rec.Body, problemTooLarge)
+				}
+				if svc.imported != 0 {
+					t.Errorf("imported %d rates from a body over the limit", svc.imported)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// ReplayedHeader marks a response served from the idempotency store.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLen = 255
+)
+
+// replayedHeaders are the response headers stored with a response.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+// recordingWriter keeps a copy of the body written through it.
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Idempotency honours the Idempotency-Key header: the first request under
+// a key runs normally and its response is stored, identical retries get
+// that response back, and the key cannot be reused for another request.
+// Server errors are not stored, so the request can be retried; so can one
+// whose handler never finished, once its lease on the key has lapsed.
+func Idempotency(svc service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLen {
+			badRequest(c, "Idempotency-Key must not exceed 255 characters")
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			handleError(c, errMalformedBody)
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		h := sha256.New()
+		io.WriteString(h, c.Request.Method+" "+c.Request.URL.Path+"\n")
+		h.Write(body)
+		hash := hex.EncodeToString(h.Sum(nil))
+
+		ctx := c.Request.Context()
+
+		prev, err := svc.Begin(ctx, key, hash)
+		if err != nil {
+			handleError(c, err)
+			return
+		}
+		if prev != nil {
+			for k, v := range prev.Header {
+				c.Header(k, v)
+			}
+			c.Header(ReplayedHeader, "true")
+			c.Status(prev.StatusCode)
+			c.Writer.Write(prev.Body)
+			c.Abort()
+			return
+		}
+
+		// The outcome is recorded even if the client has gone away.
+		bg := context.WithoutCancel(ctx)
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := svc.Abort(bg, key); err != nil {
+				slog.ErrorContext(bg, "release idempotency key", "err", err)
+			}
+		}()
+
+		w := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+
+		c.Next()
+
+		if w.Status() >= http.StatusInternalServerError {
+			return
+		}
+
+		rec := &domain.IdempotentRequest{
+			Key:         key,
+			RequestHash: hash,
+			StatusCode:  w.Status(),
+			Header:      make(map[string]string),
+			Body:        w.body.Bytes(),
+		}
+		for _, name := range replayedHeaders {
+			if v := w.Header().Get(name); v != "" {
+				rec.Header[name] = v
+			}
+		}
+
+		if err := svc.Complete(bg, rec); err != nil {
+			slog.ErrorContext(bg, "store idempotent response", "err", err)
+			return
+		}
+		completed = true
+	}
+}
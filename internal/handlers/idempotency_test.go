@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+// memIdempotency is an in-memory IdempotencyRepository on a clock the test
+// moves.
+type memIdempotency struct {
+	now     time.Time
+	records map[string]*memIdempotencyRecord
+}
+
+type memIdempotencyRecord struct {
+	rec         domain.IdempotentRequest
+	lockedUntil time.Time
+}
+
+var _ repo.IdempotencyRepository = (*memIdempotency)(nil)
+
+func (m *memIdempotency) Claim(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*domain.IdempotentRequest, bool, error) {
+	if r, ok := m.records[key]; ok && r.rec.ExpiresAt.After(m.now) && (r.rec.Completed() || r.lockedUntil.After(m.now)) {
+		rec := r.rec
+		return &rec, false, nil
+	}
+	m.records[key] = &memIdempotencyRecord{
+		rec:         domain.IdempotentRequest{Key: key, RequestHash: requestHash, ExpiresAt: m.now.Add(ttl)},
+		lockedUntil: m.now.Add(lease),
+	}
+	return nil, true, nil
+}
+
+func (m *memIdempotency) Complete(ctx context.Context, rec *domain.IdempotentRequest) error {
+	r := m.records[rec.Key]
+	r.rec.StatusCode, r.rec.Header, r.rec.Body = rec.StatusCode, rec.Header, rec.Body
+	return nil
+}
+
+func (m *memIdempotency) Release(ctx context.Context, key string) error {
+	delete(m.records, key)
+	return nil
+}
+
+func (m *memIdempotency) DeleteExpired(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+// idempotencyRig serves POST /things behind the middleware; the handler
+// counts its calls and runs during, if set, before it answers.
+type idempotencyRig struct {
+	store  *memIdempotency
+	engine *gin.Engine
+	calls  int
+	status int
+	during func()
+}
+
+func newIdempotencyRig() *idempotencyRig {
+	gin.SetMode(gin.TestMode)
+
+	rig := &idempotencyRig{
+		store:  &memIdempotency{now: time.Now(), records: map[string]*memIdempotencyRecord{}},
+		status: http.StatusCreated,
+	}
+	svc := service.NewIdempotencyService(rig.store, 24*time.Hour, time.Minute)
+
+	rig.engine = gin.New()
+	rig.engine.POST("/things", Idempotency(svc), func(c *gin.Context) {
+		rig.calls++
+		n := rig.calls
+		if rig.during != nil {
+			during := rig.during
+			rig.during = nil
+			during()
+		}
+		c.Header("Location", "/things/"+strconv.Itoa(n))
+		c.Header("ETag", `"`+strconv.Itoa(n)+`"`)
+		c.JSON(rig.status, gin.H{"call": n})
+	})
+	return rig
+}
+
+func (rig *idempotencyRig) post(key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/things", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if key != "" {
+		req.Header.Set(IdempotencyKeyHeader, key)
+	}
+	rec := httptest.NewRecorder()
+	rig.engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysCompletedRequest(t *testing.T) {
+	rig := newIdempotencyRig()
+
+	first := rig.post("k1", `{"a":1}`)
+	second := rig.post("k1", `{"a":1}`)
+
+	if rig.calls != 1 {
+		t.Fatalf("handler ran %d times, want once", rig.calls)
+	}
+	if second.Code != first.Code || second.Body.String() != first.Body.String() {
+		t.Errorf("replay: got %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	for _, h := range []string{"Location", "ETag", "Content-Type"} {
+		if got, want := second.Header().Get(h), first.Header().Get(h); got != want {
+			t.Errorf("replayed %s: got %q, want %q", h, got, want)
+		}
+	}
+	if second.Header().Get(ReplayedHeader) != "true" || first.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("%s: got %q on the replay and %q on the original", ReplayedHeader,
+			second.Header().Get(ReplayedHeader), first.Header().Get(ReplayedHeader))
+	}
+
+	if rig.post("", `{"a":1}`); rig.calls != 2 {
+		t.Errorf("a request without a key was not run")
+	}
+}
+
+func TestIdempotencyRejectsKeyReusedForAnotherBody(t *testing.T) {
+	rig := newIdempotencyRig()
+
+	rig.post("k1", `{"a":1}`)
+	rec := rig.post("k1", `{"a":2}`)
+
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), problemKeyReused) {
+		t.Errorf("got %d %s, want 422 %s", rec.Code, rec.Body, problemKeyReused)
+	}
+	if rig.calls != 1 {
+		t.Errorf("handler ran %d times, want once", rig.calls)
+	}
+}
+
+func TestIdempotencyRejectsRetryInProgress(t *testing.T) {
+	rig := newIdempotencyRig()
+
+	var retry *httptest.ResponseRecorder
+	rig.during = func() { retry = rig.post("k1", `{"a":1}`) }
+	first := rig.post("k1", `{"a":1}`)
+
+	if retry.Code != http.StatusConflict || !strings.Contains(retry.Body.String(), problemConflict) {
+		t.Errorf("retry in progress: got %d %s, want 409", retry.Code, retry.Body)
+	}
+	if first.Code != http.StatusCreated || rig.calls != 1 {
+		t.Errorf("got %d after %d calls, want 201 after one", first.Code, rig.calls)
+	}
+
+	// Once the original is answered, the retry gets its response.
+	if again := rig.post("k1", `{"a":1}`); again.Body.String() != first.Body.String() {
+		t.Errorf("after completion: got %s, want %s", again.Body, first.Body)
+	}
+}
+
+func TestIdempotencyTakesOverExpiredLease(t *testing.T) {
+	rig := newIdempotencyRig()
+
+	// The original stalls past its lease, so a retry runs the request.
+	var retry *httptest.ResponseRecorder
+	rig.during = func() {
+		rig.store.now = rig.store.now.Add(2 * time.Minute)
+		retry = rig.post("k1", `{"a":1}`)
+	}
+	rig.post("k1", `{"a":1}`)
+
+	if retry.Code != http.StatusCreated || retry.Header().Get(ReplayedHeader) != "" {
+		t.Errorf("retry after the lease: got %d %s, want a fresh 201", retry.Code, retry.Body)
+	}
+	if rig.calls != 2 {
+		t.Errorf("handler ran %d times, want twice", rig.calls)
+	}
+}
+
+func TestIdempotencyDoesNotStoreServerErrors(t *testing.T) {
+	rig := newIdempotencyRig()
+
+	rig.status = http.StatusInternalServerError
+	rig.post("k1", `{"a":1}`)
+	rig.status = http.StatusCreated
+	rec := rig.post("k1", `{"a":1}`)
+
+	if rec.Code != http.StatusCreated || rig.calls != 2 {
+		t.Errorf("retry after a server error: got %d after %d calls, want 201 after two", rec.Code, rig.calls)
+	}
+}
@@ -0,0 +1,191 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+const (
+	mergePatchContentType = "application/merge-patch+json"
+	jsonPatchContentType  = "application/json-patch+json"
+)
+
+// patchDocument is the JSON document PATCH requests operate on: the
+// writable fields of s, with absent optional values as null so that JSON
+// Patch can address them.
+func patchDocument(s *domain.Subscription) (map[string]any, error) {
+	b, err := json.Marshal(map[string]any{
+		"service_name":   s.ServiceName,
+		"service_id":     s.ServiceID,
+		"plan_id":        s.PlanID,
+		"price":          s.Price,
+		"currency":       s.Currency,
+		"billing_unit":   s.Billing.Unit,
+		"billing_count":  s.Billing.Count,
+		"start_date":     s.StartDate,
+		"end_date":       s.EndDate,
+		"trial_end_date": s.TrialEndDate,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+	return doc, json.Unmarshal(b, &doc)
+}
+
+// requiredPatchFields are the members of the patch document that must
+// stay set. The others (end_date, trial_end_date, service_id and plan_id)
+// may be nulled or removed to clear them.
+var requiredPatchFields = []string{
+	"service_name", "price", "currency", "billing_unit", "billing_count", "start_date",
+}
+
+// applyPatchDocument decodes a patched document back onto s, reporting
+// badly typed fields the same way a PUT body would. Nulling or removing a
+// required field is an error rather than a reset to its default.
+func applyPatchDocument(doc map[string]any, s *domain.Subscription) error {
+	verr := domain.NewValidationError()
+	for _, k := range requiredPatchFields {
+		if v, ok := doc[k]; !ok || v == nil {
+			verr.Add(k, "required", "must not be null or removed")
+		}
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+
+	var req UpdateSubscriptionRequest
+	if err := decodeFields(raw, &req); err != nil {
+		return err
+	}
+
+	applyUpdate(req, s)
+	return nil
+}
+
+// mergePatch applies an RFC 7396 merge patch: members of patch replace
+// those of target, recursively for objects, and null removes them.
+func mergePatch(target, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]any)
+	if !ok {
+		t = make(map[string]any)
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = mergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// jsonPatchOp is one RFC 6902 operation.
+type jsonPatchOp struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch applies RFC 6902 operations to doc in order; the first
+// failing operation aborts the whole patch. The document is flat, so
+// pointers address its top-level members only.
+func applyJSONPatch(doc map[string]any, ops []jsonPatchOp) error {
+	for i, op := range ops {
+		field := fmt.Sprintf("patch[%d]", i)
+
+		key, ok := pointerKey(op.Path)
+		if !ok {
+			return domain.NewValidationError(domain.FieldError{
+				Field: field + ".path", Code: "invalid_path", Message: "must point at a top-level member",
+			})
+		}
+
+		var value any
+		switch op.Op {
+		case "add", "replace", "test":
+			if op.Value == nil {
+				return domain.NewValidationError(domain.FieldError{
+					Field: field + ".value", Code: "required", Message: "is required",
+				})
+			}
+			if err := json.Unmarshal(op.Value, &value); err != nil {
+				return domain.NewValidationError(domain.FieldError{
+					Field: field + ".value", Code: "invalid_format", Message: "must be valid JSON",
+				})
+			}
+		case "move", "copy":
+			from, ok := pointerKey(op.From)
+			if !ok {
+				return domain.NewValidationError(domain.FieldError{
+					Field: field + ".from", Code: "invalid_path", Message: "must point at a top-level member",
+				})
+			}
+			if value, ok = doc[from]; !ok {
+				return domain.NewValidationError(domain.FieldError{
+					Field: field + ".from", Code: "path_not_found", Message: "does not exist",
+				})
+			}
+			if op.Op == "move" {
+				delete(doc, from)
+			}
+		case "remove":
+		default:
+			return domain.NewValidationError(domain.FieldError{
+				Field: field + ".op", Code: "invalid_operation",
+				Message: "must be one of add, remove, replace, move, copy, test",
+			})
+		}
+
+		current, exists := doc[key]
+		if !exists && (op.Op == "remove" || op.Op == "replace" || op.Op == "test") {
+			return domain.NewValidationError(domain.FieldError{
+				Field: field + ".path", Code: "path_not_found", Message: "does not exist",
+			})
+		}
+
+		switch op.Op {
+		case "remove":
+			delete(doc, key)
+		case "test":
+			if !reflect.DeepEqual(current, value) {
+				return domain.NewValidationError(domain.FieldError{
+					Field: field, Code: "test_failed", Message: "value at " + op.Path + " differs",
+				})
+			}
+		default:
+			doc[key] = value
+		}
+	}
+
+	return nil
+}
+
+// pointerKey resolves a JSON pointer of a single reference token.
+func pointerKey(p string) (string, bool) {
+	if !strings.HasPrefix(p, "/") || strings.Contains(p[1:], "/") {
+		return "", false
+	}
+	return strings.NewReplacer("~1", "/", "~0", "~").Replace(p[1:]), true
+}
@@ -0,0 +1,53 @@
+package handlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestMergePatchNulls(t *testing.T) {
+	end := time.Date(2025, time.December, 31, 0, 0, 0, 0, time.UTC)
+	base := domain.Subscription{
+		ServiceName: "Netflix",
+		Price:       500,
+		Currency:    "USD",
+		Billing:     domain.BillingInterval{Unit: domain.BillingYear, Count: 1},
+		StartDate:   time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+	}
+
+	tests := []struct {
+		patch     map[string]any
+		wantField string
+	}{
+		{map[string]any{"end_date": nil}, ""},
+		{map[string]any{"currency": nil}, "currency"},
+		{map[string]any{"billing_unit": nil}, "billing_unit"},
+		{map[string]any{"price": nil}, "price"},
+	}
+
+	for _, tt := range tests {
+		s := base
+		doc, err := patchDocument(&s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mergePatch(doc, tt.patch)
+		err = applyPatchDocument(doc, &s)
+
+		var verr *domain.ValidationError
+		switch {
+		case tt.wantField == "" && err != nil:
+			t.Errorf("%v: unexpected error %v", tt.patch, err)
+		case tt.wantField == "" && s.EndDate != nil:
+			t.Errorf("%v: end_date not cleared", tt.patch)
+		case tt.wantField != "" && !errors.As(err, &verr):
+			t.Errorf("%v: got %v, want a validation error", tt.patch, err)
+		case tt.wantField != "" && (len(verr.Fields) != 1 || verr.Fields[0].Field != tt.wantField):
+			t.Errorf("%v: got fields %+v, want %s", tt.patch, verr.Fields, tt.wantField)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// Pause pauses billing of a subscription
+// @Summary      Pause subscription
+// @Description  Stop billing from start_date to end_date, or until resumed when end_date is omitted.
+// @Description  Billing dates keep their anchor; those inside the pause are not charged, and the
+// @Description  subscription does not count as active while paused.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        pause body PauseRequest true "Pause"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/pause [post]
+func (h *SubscriptionHandler) Pause(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req PauseRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sub, err := h.svc.Pause(c.Request.Context(), id, version, domain.Pause{
+		StartDate: req.StartDate,
+		EndDate:   req.EndDate,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+// Resume resumes billing of a paused subscription
+// @Summary      Resume subscription
+// @Description  End the pause covering date (default today) so that billing resumes that day;
+// @Description  resuming on the first day of a pause cancels it. The body may be omitted.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        resume body ResumeRequest false "Resume"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Not paused on that day"
+// @Failure      412 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/resume [post]
+func (h *SubscriptionHandler) Resume(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req ResumeRequest
+	if c.Request.ContentLength != 0 {
+		if err := bindJSON(c, &req); err != nil {
+			handleError(c, err)
+			return
+		}
+	}
+
+	on := time.Now()
+	if req.Date != nil {
+		on = *req.Date
+	}
+
+	sub, err := h.svc.Resume(c.Request.Context(), id, version, on)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+func toPauseResponses(pauses []domain.Pause) []PauseResponse {
+	if len(pauses) == 0 {
+		return nil
+	}
+
+	res := make([]PauseResponse, 0, len(pauses))
+	for _, p := range pauses {
+		res = append(res, PauseResponse(p))
+	}
+	return res
+}
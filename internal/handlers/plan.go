@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// ChangePlan moves a subscription to another plan
+// @Summary      Change subscription plan
+// @Description  Upgrade or downgrade to another plan of the service from effective_from on, in the past or
+// @Description  the future. A new billing cycle at the price and interval of the plan starts that day; totals
+// @Description  before it keep the old plan. A change on the same day is replaced.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        change body PlanChangeRequest true "Plan change"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/plans [post]
+func (h *SubscriptionHandler) ChangePlan(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req PlanChangeRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sub, err := h.svc.ChangePlan(c.Request.Context(), id, version, req.PlanID, req.EffectiveFrom)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+// CancelPlanChange removes a plan change
+// @Summary      Cancel plan change
+// @Description  Remove the plan change effective on the given day
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        effective_from path string true "Day of the change (YYYY-MM-DD)"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/plans/{effective_from} [delete]
+func (h *SubscriptionHandler) CancelPlanChange(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	day, err := time.Parse(time.DateOnly, c.Param("effective_from"))
+	if err != nil {
+		badRequest(c, "invalid effective_from")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.svc.CancelPlanChange(c.Request.Context(), id, version, day)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+func toPlanChangeResponses(changes []domain.PlanChange) []PlanChangeResponse {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	res := make([]PlanChangeResponse, 0, len(changes))
+	for _, pc := range changes {
+		res = append(res, PlanChangeResponse{
+			EffectiveFrom: pc.EffectiveFrom,
+			PlanID:        pc.PlanID,
+			PlanName:      pc.PlanName,
+			Price:         pc.Price,
+			BillingUnit:   string(pc.Billing.Unit),
+			BillingCount:  pc.Billing.Count,
+		})
+	}
+	return res
+}
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// Prices returns the price timeline of a subscription
+// @Summary      Subscription price timeline
+// @Description  The prices of the subscription over its lifetime, including scheduled price and plan changes
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Success      200 {object} PriceTimelineResponse
+// @Header       200 {string} ETag "Current version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/prices [get]
+func (h *SubscriptionHandler) Prices(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	sub, err := h.svc.GetByID(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toPriceTimeline(sub))
+}
+
+// ChangePrice records a price change
+// @Summary      Change subscription price
+// @Description  Charge a new price from effective_from on, in the past or the future. Unlike updating
+// @Description  price, totals before effective_from keep the old price. A change on the same day is replaced.
+// @Tags         subscriptions
+// @Accept       json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        change body PriceChangeRequest true "Price change"
+// @Success      200 {object} PriceTimelineResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/prices [post]
+func (h *SubscriptionHandler) ChangePrice(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var req PriceChangeRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
+	}
+
+	sub, err := h.svc.ChangePrice(c.Request.Context(), id, version, domain.PriceChange{
+		EffectiveFrom: req.EffectiveFrom,
+		Price:         req.Price,
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toPriceTimeline(sub))
+}
+
+// CancelPriceChange removes a price change
+// @Summary      Cancel price change
+// @Description  Remove the price change effective on the given day
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        effective_from path string true "Day of the change (YYYY-MM-DD)"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Success      200 {object} PriceTimelineResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/prices/{effective_from} [delete]
+func (h *SubscriptionHandler) CancelPriceChange(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	day, err := time.Parse(time.DateOnly, c.Param("effective_from"))
+	if err != nil {
+		badRequest(c, "invalid effective_from")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	sub, err := h.svc.CancelPriceChange(c.Request.Context(), id, version, day)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toPriceTimeline(sub))
+}
+
+// toPriceTimeline splits the lifetime of s into periods of one price, set
+// by a price or a plan change. Changes outside the lifetime have no period
+// of their own.
+func toPriceTimeline(s *domain.Subscription) PriceTimelineResponse {
+	start := s.StartDate
+	periods := []PricePeriodResponse{{EffectiveFrom: start, Price: s.PriceOn(start)}}
+
+	var days []time.Time
+	for _, pc := range s.PriceChanges {
+		days = append(days, pc.EffectiveFrom)
+	}
+	for _, pc := range s.PlanChanges {
+		days = append(days, pc.EffectiveFrom)
+	}
+	slices.SortFunc(days, time.Time.Compare)
+	days = slices.CompactFunc(days, time.Time.Equal)
+
+	for _, day := range days {
+		if !day.After(start) {
+			continue
+		}
+		if s.EndDate != nil && day.After(*s.EndDate) {
+			break
+		}
+		last := day.AddDate(0, 0, -1)
+		periods[len(periods)-1].EffectiveTo = &last
+		periods = append(periods, PricePeriodResponse{EffectiveFrom: day, Price: s.PriceOn(day)})
+	}
+	periods[len(periods)-1].EffectiveTo = s.EndDate
+
+	return PriceTimelineResponse{
+		SubscriptionID: s.ID,
+		Currency:       s.Currency,
+		Periods:        periods,
+		ETag:           etag(s.Version),
+	}
+}
+
+func toPriceChangeResponses(changes []domain.PriceChange) []PriceChangeResponse {
+	if len(changes) == 0 {
+		return nil
+	}
+
+	res := make([]PriceChangeResponse, 0, len(changes))
+	for _, pc := range changes {
+		res = append(res, PriceChangeResponse(pc))
+	}
+	return res
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/httpserver"
+)
+
+const problemContentType = "application/problem+json"
+
+// Problem types, relative to the API host.
+const (
+	problemBadRequest     = "/problems/bad-request"
+	problemForbidden      = "/problems/forbidden"
+	problemNotFound       = "/problems/not-found"
+	problemConflict       = "/problems/conflict"
+	problemPrecondition   = "/problems/precondition-failed"
+	problemValidation     = "/problems/validation-failed"
+	problemNoExchangeRate = "/problems/exchange-rate-missing"
+	problemKeyReused      = "/problems/idempotency-key-reused"
+	problemBatchAborted   = "/problems/batch-aborted"
+	problemNoRoute        = "/problems/route-not-found"
+	problemNoMethod       = "/problems/method-not-allowed"
+	problemMediaType      = "/problems/unsupported-media-type"
+	problemTooLarge       = "/problems/payload-too-large"
+	problemInternal       = "/problems/internal-error"
+)
+
+// Problem is an RFC 7807 problem details object.
+//
+// @name Problem
+type Problem struct {
+	Type      string               `json:"type" example:"/problems/validation-failed"`
+	Title     string               `json:"title" example:"Unprocessable Entity"`
+	Status    int                  `json:"status" example:"422"`
+	Detail    string               `json:"detail,omitempty"`
+	Instance  string               `json:"instance,omitempty" example:"/api/v1/subscriptions"`
+	RequestID string               `json:"request_id,omitempty"`
+	Errors    []FieldErrorResponse `json:"errors,omitempty"`
+}
+
+func writeProblem(c *gin.Context, status int, typ, detail string, fields []domain.FieldError) {
+	p := Problem{
+		Type:      typ,
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.Request.URL.Path,
+		RequestID: httpserver.GetRequestID(c),
+	}
+	if len(fields) > 0 {
+		p.Errors = toFieldErrors(fields)
+	}
+
+	c.Header("Content-Type", problemContentType)
+	c.AbortWithStatusJSON(status, p)
+}
+
+func badRequest(c *gin.Context, detail string) {
+	writeProblem(c, http.StatusBadRequest, problemBadRequest, detail, nil)
+}
+
+// requireAdmin answers 403 unless the caller has httpserver.RoleAdmin.
+func requireAdmin(c *gin.Context, what string) bool {
+	if httpserver.IsAdmin(c) {
+		return true
+	}
+	writeProblem(c, http.StatusForbidden, problemForbidden, what+" is for admins only", nil)
+	return false
+}
+
+// NoRoute answers unknown paths with a problem body.
+func NoRoute(c *gin.Context) {
+	writeProblem(c, http.StatusNotFound, problemNoRoute, "no route for "+c.Request.Method+" "+c.Request.URL.Path, nil)
+}
+
+// NoMethod answers known paths requested with an unsupported method.
+func NoMethod(c *gin.Context) {
+	writeProblem(c, http.StatusMethodNotAllowed, problemNoMethod, "method "+c.Request.Method+" is not allowed", nil)
+}
+
+// Recovery turns panics into a 500 problem body.
+func Recovery(c *gin.Context, _ any) {
+	writeProblem(c, http.StatusInternalServerError, problemInternal, "", nil)
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,32 +28,31 @@ func NewSubscriptionHandler(svc service.SubscriptionService) *SubscriptionHandle
 // @Tags         subscriptions
 // @Accept       json
 // @Produce      json
+// @Param        Idempotency-Key header string false "Unique key of this creation; retries with the same key and body replay the first response"
 // @Param        subscription body CreateSubscriptionRequest true "Subscription data"
 // @Success      201 {object} SubscriptionResponse
-// @Failure      400 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Header       201 {string} Idempotent-Replayed "true when the response is a replay"
+// @Failure      400 {object} Problem
+// @Failure      409 {object} Problem "Conflict, or a request with the same Idempotency-Key is in progress"
+// @Failure      422 {object} Problem "Invalid fields, or Idempotency-Key reused with a different body"
+// @Failure      500 {object} Problem
 // @Router       /subscriptions [post]
 func (h *SubscriptionHandler) Create(c *gin.Context) {
 	var req CreateSubscriptionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
 		return
 	}
 
-	sub := &domain.Subscription{
-		UserID:      req.UserID,
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
-	}
+	sub := fromCreateRequest(req)
 
-	if err := h.svc.Create(c.Request.Context(), sub); err != nil {
+	if err := h.svc.Create(c.Request.Context(), &sub); err != nil {
 		handleError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, toResponse(sub))
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusCreated, toResponse(&sub))
 }
 
 // GetByID gets subscription by ID
@@ -61,14 +62,15 @@ func (h *SubscriptionHandler) Create(c *gin.Context) {
 // @Produce      json
 // @Param        id path string true "Subscription ID"
 // @Success      200 {object} SubscriptionResponse
-// @Failure      400 {object} map[string]string
-// @Failure      404 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Header       200 {string} ETag "Current version, for If-Match"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      500 {object} Problem
 // @Router       /subscriptions/{id} [get]
 func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		badRequest(c, "invalid id")
 		return
 	}
 
@@ -77,11 +79,8 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 		handleError(c, err)
 		return
 	}
-	if sub == nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
-		return
-	}
 
+	c.Header("ETag", etag(sub.Version))
 	c.JSON(http.StatusOK, toResponse(sub))
 }
 
@@ -92,59 +91,158 @@ func (h *SubscriptionHandler) GetByID(c *gin.Context) {
 // @Accept       json
 // @Produce      json
 // @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
 // @Param        subscription body UpdateSubscriptionRequest true "Subscription data"
 // @Success      204
-// @Failure      400 {object} map[string]string
-// @Failure      404 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Header       204 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
 // @Router       /subscriptions/{id} [put]
 func (h *SubscriptionHandler) Update(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		badRequest(c, "invalid id")
 		return
 	}
 
-	var req UpdateSubscriptionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+	version, ok := ifMatchVersion(c)
+	if !ok {
 		return
 	}
 
-	sub := &domain.Subscription{
-		ID:          id,
-		ServiceName: req.ServiceName,
-		Price:       req.Price,
-		StartDate:   req.StartDate,
-		EndDate:     req.EndDate,
+	var req UpdateSubscriptionRequest
+	if err := bindJSON(c, &req); err != nil {
+		handleError(c, err)
+		return
 	}
 
+	sub := &domain.Subscription{ID: id, Version: version}
+	applyUpdate(req, sub)
+
 	if err := h.svc.Update(c.Request.Context(), sub); err != nil {
 		handleError(c, err)
 		return
 	}
 
+	c.Header("ETag", etag(sub.Version))
 	c.Status(http.StatusNoContent)
 }
 
+// Patch partially updates subscription by ID
+// @Summary      Patch subscription
+// @Description  Change some fields of a subscription. With application/merge-patch+json (RFC 7396,
+// @Description  also assumed for application/json) only the given fields change and null clears end_date,
+// @Description  trial_end_date, service_id or plan_id; other fields cannot be nulled (422). With
+// @Description  application/json-patch+json (RFC 6902) the operations apply to the fields of
+// @Description  UpdateSubscriptionRequest. The result is validated as a whole and saved atomically.
+// @Tags         subscriptions
+// @Accept       json
+// @Accept       application/merge-patch+json
+// @Accept       application/json-patch+json
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the change is based on"
+// @Param        patch body object true "Merge patch object or JSON Patch array"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      415 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id} [patch]
+func (h *SubscriptionHandler) Patch(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var apply func(doc map[string]any) error
+
+	switch c.ContentType() {
+	case mergePatchContentType, "application/json", "":
+		var patch map[string]any
+		if err := json.NewDecoder(c.Request.Body).Decode(&patch); err != nil || patch == nil {
+			badRequest(c, "body must be a JSON object")
+			return
+		}
+		apply = func(doc map[string]any) error {
+			mergePatch(doc, patch)
+			return nil
+		}
+
+	case jsonPatchContentType:
+		var ops []jsonPatchOp
+		if err := json.NewDecoder(c.Request.Body).Decode(&ops); err != nil {
+			badRequest(c, "body must be an array of JSON Patch operations")
+			return
+		}
+		apply = func(doc map[string]any) error {
+			return applyJSONPatch(doc, ops)
+		}
+
+	default:
+		writeProblem(c, http.StatusUnsupportedMediaType, problemMediaType,
+			"use "+mergePatchContentType+" or "+jsonPatchContentType, nil)
+		return
+	}
+
+	sub, err := h.svc.Patch(c.Request.Context(), id, version, func(s *domain.Subscription) error {
+		doc, err := patchDocument(s)
+		if err != nil {
+			return err
+		}
+		if err := apply(doc); err != nil {
+			return err
+		}
+		return applyPatchDocument(doc, s)
+	})
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
 // Delete deletes subscription by ID
 // @Summary      Delete subscription
-// @Description  Delete subscription by ID
+// @Description  Move subscription to the trash; it can be restored until the retention window ends
 // @Tags         subscriptions
 // @Param        id path string true "Subscription ID"
+// @Param        If-Match header string false "ETag the deletion is based on"
 // @Success      204
-// @Failure      400 {object} map[string]string
-// @Failure      404 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      412 {object} Problem
+// @Failure      500 {object} Problem
 // @Router       /subscriptions/{id} [delete]
 func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		badRequest(c, "invalid id")
 		return
 	}
 
-	if err := h.svc.Delete(c.Request.Context(), id); err != nil {
+	version, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	if err := h.svc.Delete(c.Request.Context(), id, version); err != nil {
 		handleError(c, err)
 		return
 	}
@@ -152,92 +250,414 @@ func (h *SubscriptionHandler) Delete(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
+// Restore restores a deleted subscription
+// @Summary      Restore subscription
+// @Description  Bring a deleted subscription back from the trash
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Success      200 {object} SubscriptionResponse
+// @Header       200 {string} ETag "New version"
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      409 {object} Problem "Subscription is not deleted"
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/restore [post]
+func (h *SubscriptionHandler) Restore(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	sub, err := h.svc.Restore(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	c.Header("ETag", etag(sub.Version))
+	c.JSON(http.StatusOK, toResponse(sub))
+}
+
+// History returns the change history of a subscription
+// @Summary      Subscription history
+// @Description  Every create, update, delete and restore of the subscription, oldest first,
+// @Description  with the state before and after the change and who made it (X-Actor header)
+// @Tags         subscriptions
+// @Produce      json
+// @Param        id path string true "Subscription ID"
+// @Success      200 {object} SubscriptionHistoryResponse
+// @Failure      400 {object} Problem
+// @Failure      404 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/{id}/history [get]
+func (h *SubscriptionHandler) History(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		badRequest(c, "invalid id")
+		return
+	}
+
+	events, err := h.svc.History(c.Request.Context(), id)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	resp := SubscriptionHistoryResponse{
+		SubscriptionID: id,
+		Events:         make([]SubscriptionEventResponse, 0, len(events)),
+	}
+	for i := range events {
+		resp.Events = append(resp.Events, toEventResponse(&events[i]))
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
 // List lists subscriptions
 // @Summary      List subscriptions
-// @Description  List subscriptions with filters
+// @Description  List subscriptions with filters, newest first unless sort is given. Pages can be
+// @Description  walked with limit/offset or, stable under concurrent inserts, with cursor;
+// @Description  the response carries the total match count and links to neighbouring pages.
 // @Tags         subscriptions
 // @Produce      json
 // @Param        user_id query string false "User ID"
-// @Param        service_name query string false "Service name"
-// @Param        from query string false "From date (YYYY-MM-01)"
-// @Param        to query string false "To date (YYYY-MM-01)"
-// @Param        limit query int false "Limit"
-// @Param        offset query int false "Offset"
-// @Success      200 {array} SubscriptionResponse
-// @Failure      400 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Param        service_id query string false "Catalog service ID"
+// @Param        service_name query string false "Service name; unless matched by prefix, also matches subscriptions linked to the catalog service of that name"
+// @Param        service_name_match query string false "How service_name is matched (default exact)" Enums(exact, ignore_case, prefix)
+// @Param        from query string false "Started on or before (YYYY-MM-DD)"
+// @Param        to query string false "Not ended before (YYYY-MM-DD)"
+// @Param        price_min query int false "Minimum price, inclusive"
+// @Param        price_max query int false "Maximum price, inclusive"
+// @Param        active_on query string false "Active on this day (YYYY-MM-DD)"
+// @Param        status query string false "Status as of today" Enums(active, paused, ended, upcoming)
+// @Param        trial_ending query int false "Trial ends between today and this many days from now"
+// @Param        include_deleted query bool false "Also list deleted subscriptions; admins only"
+// @Param        sort query []string false "Sort keys as field[:asc|desc], e.g. price:desc,start_date; fields: price, start_date, end_date, service_name, created_at, trial_end_date" collectionFormat(csv)
+// @Param        limit query int false "Page size (default 20, max 100)"
+// @Param        offset query int false "Offset; not allowed together with cursor"
+// @Param        cursor query string false "next_cursor of the previous page, requested with the same sort"
+// @Success      200 {object} SubscriptionListResponse
+// @Failure      400 {object} Problem
+// @Failure      403 {object} Problem "include_deleted without the admin role"
+// @Failure      500 {object} Problem
 // @Router       /subscriptions [get]
 func (h *SubscriptionHandler) List(c *gin.Context) {
-	var (
-		userID      *uuid.UUID
-		serviceName *string
-		from, to    *time.Time
-	)
+	f, ok := parseListFilter(c)
+	if !ok {
+		return
+	}
+
+	h.list(c, f)
+}
+
+// Trash lists deleted subscriptions
+// @Summary      List deleted subscriptions
+// @Description  Subscriptions deleted within the retention window, which can still be restored.
+// @Description  Accepts the same filters, sorting and paging as GET /subscriptions.
+// @Description  Admins only: the gateway must send X-Actor with X-Actor-Role: admin.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        user_id query string false "User ID"
+// @Param        service_name query string false "Service name"
+// @Param        sort query []string false "Sort keys as field[:asc|desc]" collectionFormat(csv)
+// @Param        limit query int false "Page size (default 20, max 100)"
+// @Param        offset query int false "Offset; not allowed together with cursor"
+// @Param        cursor query string false "next_cursor of the previous page, requested with the same sort"
+// @Success      200 {object} SubscriptionListResponse
+// @Failure      400 {object} Problem
+// @Failure      403 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/trash [get]
+func (h *SubscriptionHandler) Trash(c *gin.Context) {
+	if !requireAdmin(c, "the trash") {
+		return
+	}
+	f, ok := parseListFilter(c)
+	if !ok {
+		return
+	}
+	f.OnlyDeleted = true
+
+	h.list(c, f)
+}
+
+func (h *SubscriptionHandler) list(c *gin.Context, f service.ListFilter) {
+	res, err := h.svc.List(c.Request.Context(), f)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	resp := SubscriptionListResponse{
+		Items:      make([]SubscriptionResponse, 0, len(res.Items)),
+		TotalCount: res.TotalCount,
+		Limit:      res.Limit,
+		NextCursor: res.NextCursor,
+		Links:      PageLinks{Self: c.Request.URL.RequestURI()},
+	}
+	for i := range res.Items {
+		resp.Items = append(resp.Items, toResponse(&res.Items[i]))
+	}
+
+	if f.Cursor != "" {
+		if res.NextCursor != "" {
+			resp.Links.Next = pageLink(c, map[string]string{"cursor": res.NextCursor})
+		}
+	} else {
+		resp.Offset = &res.Offset
+		if res.NextCursor != "" {
+			resp.Links.Next = pageLink(c, map[string]string{"offset": strconv.Itoa(res.Offset + res.Limit)})
+		}
+		if res.Offset > 0 {
+			resp.Links.Prev = pageLink(c, map[string]string{"offset": strconv.Itoa(max(res.Offset-res.Limit, 0))})
+		}
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+// pageLink is the current request URI with the given query parameters
+// replaced.
+func pageLink(c *gin.Context, set map[string]string) string {
+	q := c.Request.URL.Query()
+	for k, v := range set {
+		q.Set(k, v)
+	}
+
+	u := *c.Request.URL
+	u.RawQuery = q.Encode()
+	return u.RequestURI()
+}
+
+// parseListFilter reads the list query parameters, answering 400 itself
+// when one is invalid and 403 when it is for admins only.
+func parseListFilter(c *gin.Context) (service.ListFilter, bool) {
+	var f service.ListFilter
 
 	if v := c.Query("user_id"); v != "" {
 		u, err := uuid.Parse(v)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
-			return
+			badRequest(c, "invalid user_id")
+			return f, false
+		}
+		f.UserID = &u
+	}
+
+	if v := c.Query("service_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			badRequest(c, "invalid service_id")
+			return f, false
 		}
-		userID = &u
+		f.ServiceID = &id
 	}
 
 	if v := c.Query("service_name"); v != "" {
-		serviceName = &v
+		f.ServiceName = &v
+	}
+
+	f.ServiceNameMatch = service.NameMatch(c.DefaultQuery("service_name_match", string(service.NameMatchExact)))
+	if !f.ServiceNameMatch.Valid() {
+		badRequest(c, "invalid service_name_match")
+		return f, false
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **time.Time
+	}{{"from", &f.From}, {"to", &f.To}, {"active_on", &f.ActiveOn}} {
+		if v := c.Query(p.name); v != "" {
+			t, err := time.Parse(time.DateOnly, v)
+			if err != nil {
+				badRequest(c, "invalid "+p.name)
+				return f, false
+			}
+			*p.dst = &t
+		}
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **int
+	}{{"price_min", &f.PriceMin}, {"price_max", &f.PriceMax}} {
+		if v := c.Query(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				badRequest(c, "invalid "+p.name)
+				return f, false
+			}
+			*p.dst = &n
+		}
+	}
+	if f.PriceMin != nil && f.PriceMax != nil && *f.PriceMin > *f.PriceMax {
+		badRequest(c, "price_min must not exceed price_max")
+		return f, false
 	}
 
-	if v := c.Query("from"); v != "" {
-		t, err := time.Parse("2006-01-02", v)
+	if v := c.Query("status"); v != "" {
+		f.Status = domain.Status(v)
+		if !f.Status.Valid() {
+			badRequest(c, "invalid status")
+			return f, false
+		}
+	}
+
+	if v := c.Query("trial_ending"); v != "" {
+		n, err := parseTrialDays(v)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
-			return
+			badRequest(c, "invalid trial_ending")
+			return f, false
 		}
-		from = &t
+		f.TrialEndingWithin = &n
 	}
 
-	if v := c.Query("to"); v != "" {
-		t, err := time.Parse("2006-01-02", v)
+	if v := c.Query("include_deleted"); v != "" {
+		b, err := strconv.ParseBool(v)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
-			return
+			badRequest(c, "invalid include_deleted")
+			return f, false
+		}
+		if b && !requireAdmin(c, "include_deleted") {
+			return f, false
 		}
-		to = &t
+		f.IncludeDeleted = b
 	}
 
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	offset, _ := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	seen := make(map[service.SortField]bool)
+	for _, raw := range c.QueryArray("sort") {
+		for _, v := range strings.Split(raw, ",") {
+			v = strings.TrimSpace(v)
+			if v == "" {
+				continue
+			}
+			field, dir, _ := strings.Cut(v, ":")
+			k := service.SortKey{Field: service.SortField(field)}
+			switch dir {
+			case "", "asc":
+			case "desc":
+				k.Desc = true
+			default:
+				badRequest(c, "invalid sort direction "+dir)
+				return f, false
+			}
+			if !k.Field.Valid() || seen[k.Field] {
+				badRequest(c, "invalid sort field "+field)
+				return f, false
+			}
+			seen[k.Field] = true
+			f.Sort = append(f.Sort, k)
+		}
+	}
 
-	subs, err := h.svc.List(c.Request.Context(), service.ListFilter{
-		UserID:      userID,
-		ServiceName: serviceName,
-		From:        from,
-		To:          to,
-		Limit:       limit,
-		Offset:      offset,
-	})
-	if err != nil {
-		handleError(c, err)
-		return
+	var err error
+	f.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil || f.Limit < 0 {
+		badRequest(c, "invalid limit")
+		return f, false
 	}
 
-	resp := make([]SubscriptionResponse, 0, len(subs))
-	for i := range subs {
-		resp = append(resp, toResponse(&subs[i]))
+	f.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || f.Offset < 0 {
+		badRequest(c, "invalid offset")
+		return f, false
 	}
 
-	c.JSON(http.StatusOK, resp)
+	f.Cursor = c.Query("cursor")
+	if f.Cursor != "" && f.Offset > 0 {
+		badRequest(c, "offset cannot be combined with cursor")
+		return f, false
+	}
+
+	return f, true
+}
+
+func fromCreateRequest(req CreateSubscriptionRequest) domain.Subscription {
+	return domain.Subscription{
+		UserID:      req.UserID,
+		ServiceName: req.ServiceName,
+		ServiceID:   req.ServiceID,
+		PlanID:      req.PlanID,
+		Price:       req.Price,
+		Currency:    req.Currency,
+		Billing: domain.BillingInterval{
+			Unit:  domain.BillingUnit(req.BillingUnit),
+			Count: req.BillingCount,
+		},
+		StartDate:    req.StartDate,
+		EndDate:      req.EndDate,
+		TrialEndDate: trialEndDate(req.StartDate, req.TrialEndDate, req.TrialDays),
+	}
+}
+
+func applyUpdate(req UpdateSubscriptionRequest, s *domain.Subscription) {
+	s.ServiceName = req.ServiceName
+	s.ServiceID = req.ServiceID
+	s.PlanID = req.PlanID
+	s.Price = req.Price
+	s.Currency = req.Currency
+	s.Billing = domain.BillingInterval{
+		Unit:  domain.BillingUnit(req.BillingUnit),
+		Count: req.BillingCount,
+	}
+	s.StartDate = req.StartDate
+	s.EndDate = req.EndDate
+	s.TrialEndDate = trialEndDate(req.StartDate, req.TrialEndDate, req.TrialDays)
+}
+
+// trialEndDate resolves a trial given as a length, which takes precedence,
+// or as its last day.
+func trialEndDate(start time.Time, end *time.Time, days int) *time.Time {
+	if days == 0 {
+		return end
+	}
+	last := start.AddDate(0, 0, days-1)
+	return &last
 }
 
 func toResponse(s *domain.Subscription) SubscriptionResponse {
 	return SubscriptionResponse{
-		ID:          s.ID,
-		UserID:      s.UserID,
-		ServiceName: s.ServiceName,
-		Price:       s.Price,
-		StartDate:   s.StartDate,
-		EndDate:     s.EndDate,
-		CreatedAt:   s.CreatedAt,
-		UpdatedAt:   s.UpdatedAt,
+		ID:            s.ID,
+		UserID:        s.UserID,
+		ServiceName:   s.ServiceName,
+		ServiceID:     s.ServiceID,
+		PlanID:        s.PlanID,
+		CurrentPlanID: s.PlanOn(time.Now().UTC()),
+		Price:         s.Price,
+		Currency:      s.Currency,
+		BillingUnit:   string(s.Billing.Unit),
+		BillingCount:  s.Billing.Count,
+		StartDate:     s.StartDate,
+		EndDate:       s.EndDate,
+		TrialEndDate:  s.TrialEndDate,
+		PriceChanges:  toPriceChangeResponses(s.PriceChanges),
+		PlanChanges:   toPlanChangeResponses(s.PlanChanges),
+		Pauses:        toPauseResponses(s.Pauses),
+		Discounts:     toDiscountResponses(s.Discounts),
+		ETag:          etag(s.Version),
+		CreatedAt:     s.CreatedAt,
+		UpdatedAt:     s.UpdatedAt,
+		DeletedAt:     s.DeletedAt,
+	}
+}
+
+func toEventResponse(e *domain.SubscriptionEvent) SubscriptionEventResponse {
+	resp := SubscriptionEventResponse{
+		ID:         e.ID,
+		Type:       string(e.Type),
+		Actor:      e.Actor,
+		RequestID:  e.RequestID,
+		OccurredAt: e.OccurredAt,
+	}
+	if e.Before != nil {
+		b := toResponse(e.Before)
+		resp.Before = &b
+	}
+	if e.After != nil {
+		a := toResponse(e.After)
+		resp.After = &a
 	}
+	return resp
 }
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/httpserver"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+// listService records the filter of the last List.
+type listService struct {
+	service.SubscriptionService
+	filter *service.ListFilter
+}
+
+func (s *listService) List(ctx context.Context, f service.ListFilter) (service.ListResult, error) {
+	s.filter = &f
+	return service.ListResult{}, nil
+}
+
+func TestDeletedSubscriptionsAreForAdmins(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		target     string
+		actor      string
+		role       string
+		wantStatus int
+	}{
+		{"list", "/subscriptions", "", "", http.StatusOK},
+		{"include_deleted=false", "/subscriptions?include_deleted=false", "", "", http.StatusOK},
+		{"include_deleted anonymously", "/subscriptions?include_deleted=true", "", "", http.StatusForbidden},
+		{"include_deleted as a user", "/subscriptions?include_deleted=true", "alice", "user", http.StatusForbidden},
+		{"include_deleted as an admin without a name", "/subscriptions?include_deleted=true", "", httpserver.RoleAdmin, http.StatusForbidden},
+		{"include_deleted as an admin", "/subscriptions?include_deleted=true", "root", httpserver.RoleAdmin, http.StatusOK},
+		{"trash anonymously", "/subscriptions/trash", "", "", http.StatusForbidden},
+		{"trash as a user", "/subscriptions/trash", "alice", "user", http.StatusForbidden},
+		{"trash as an admin", "/subscriptions/trash", "root", httpserver.RoleAdmin, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &listService{}
+			h := NewSubscriptionHandler(svc)
+			r := gin.New()
+			r.Use(httpserver.Actor())
+			r.GET("/subscriptions", h.List)
+			r.GET("/subscriptions/trash", h.Trash)
+
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.actor != "" {
+				req.Header.Set(httpserver.ActorHeader, tt.actor)
+			}
+			if tt.role != "" {
+				req.Header.Set(httpserver.RoleHeader, tt.role)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if called := svc.filter != nil; called != (tt.wantStatus == http.StatusOK) {
+				t.Errorf("service called: %v", called)
+			}
+		})
+	}
+}
@@ -2,11 +2,13 @@ package handlers
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 	"github.com/RomaNano/subscriptions-aggregator/internal/service"
 )
 
@@ -20,63 +22,213 @@ func NewTotalHandler(svc service.SubscriptionService) *TotalHandler {
 
 // Get calculates total subscription cost
 // @Summary      Get total subscription cost
-// @Description  Calculate total cost of subscriptions for a period
+// @Description  Calculate total cost of subscriptions for a period.
+// @Description  mode=billed (default) charges the price on every billing date inside the period;
+// @Description  mode=monthly_equivalent charges price / interval length for every active month, for budgeting views;
+// @Description  mode=prorated charges each billing period by the share of its days inside the period, using exact dates.
+// @Description  total is net of discounts; gross and discount show what was taken off.
 // @Tags         subscriptions
 // @Produce      json
-// @Param        from query string true  "From date (YYYY-MM)"
-// @Param        to   query string true  "To date (YYYY-MM)"
+// @Param        from query string true  "From month (YYYY-MM) or day (YYYY-MM-DD)"
+// @Param        to   query string true  "To month (YYYY-MM) or day (YYYY-MM-DD), inclusive"
 // @Param        user_id query string false "User ID"
-// @Param        service_name query string false "Service name"
+// @Param        service_id query string false "Catalog service ID"
+// @Param        service_name query string false "Service name; also matches subscriptions linked to the catalog service of that name"
+// @Param        mode query string false "Calculation mode" Enums(billed, monthly_equivalent, prorated)
+// @Param        currency query string false "Convert the total into this ISO 4217 currency, each month at the rate in effect at its end"
 // @Success      200 {object} TotalResponse
-// @Failure      400 {object} map[string]string
-// @Failure      500 {object} map[string]string
+// @Failure      400 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
 // @Router       /subscriptions/total [get]
 func (h *TotalHandler) Get(c *gin.Context) {
-	// --- required params ---
-	fromStr := c.Query("from")
-	toStr := c.Query("to")
+	f, ok := parseTotalFilter(c)
+	if !ok {
+		return
+	}
 
-	from, err := time.Parse("2006-01", fromStr)
+	total, err := h.svc.Total(c.Request.Context(), f)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from"})
+		handleError(c, err)
 		return
 	}
 
-	to, err := time.Parse("2006-01", toStr)
+	c.JSON(http.StatusOK, toTotalResponse(total))
+}
+
+// Breakdown calculates subscription cost grouped by dimensions
+// @Summary      Get subscription cost breakdown
+// @Description  Same calculation as /subscriptions/total, grouped by any combination of
+// @Description  service_name, user_id and calendar month, with per-group subtotals and a grand total;
+// @Description  every total shows gross, discount and net (total) amounts.
+// @Tags         subscriptions
+// @Produce      json
+// @Param        from query string true  "From month (YYYY-MM) or day (YYYY-MM-DD)"
+// @Param        to   query string true  "To month (YYYY-MM) or day (YYYY-MM-DD), inclusive"
+// @Param        group_by query []string false "Dimensions to group by" collectionFormat(csv) Enums(service_name, user_id, month)
+// @Param        user_id query string false "User ID"
+// @Param        service_id query string false "Catalog service ID"
+// @Param        service_name query string false "Service name; also matches subscriptions linked to the catalog service of that name"
+// @Param        mode query string false "Calculation mode" Enums(billed, monthly_equivalent, prorated)
+// @Param        currency query string false "Convert totals into this ISO 4217 currency, each month at the rate in effect at its end"
+// @Success      200 {object} BreakdownResponse
+// @Failure      400 {object} Problem
+// @Failure      422 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/breakdown [get]
+func (h *TotalHandler) Breakdown(c *gin.Context) {
+	f, ok := parseTotalFilter(c)
+	if !ok {
+		return
+	}
+
+	var groupBy []service.GroupBy
+	seen := make(map[service.GroupBy]bool)
+	for _, raw := range c.QueryArray("group_by") {
+		for _, v := range strings.Split(raw, ",") {
+			g := service.GroupBy(strings.TrimSpace(v))
+			if g == "" || seen[g] {
+				continue
+			}
+			if !g.Valid() {
+				badRequest(c, "invalid group_by")
+				return
+			}
+			seen[g] = true
+			groupBy = append(groupBy, g)
+		}
+	}
+
+	res, err := h.svc.Breakdown(c.Request.Context(), service.BreakdownFilter{
+		TotalFilter: f,
+		GroupBy:     groupBy,
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to"})
+		handleError(c, err)
 		return
 	}
 
+	c.JSON(http.StatusOK, toBreakdownResponse(res))
+}
+
+// parseTotalFilter reads the query parameters shared by total and
+// breakdown, answering 400 itself when one is invalid.
+func parseTotalFilter(c *gin.Context) (service.TotalFilter, bool) {
+	var f service.TotalFilter
+
+	// --- required params ---
+	from, err := parsePeriodBound(c.Query("from"), false)
+	if err != nil {
+		badRequest(c, "invalid from")
+		return f, false
+	}
+
+	to, err := parsePeriodBound(c.Query("to"), true)
+	if err != nil {
+		badRequest(c, "invalid to")
+		return f, false
+	}
+
+	f.From = from
+	f.To = to
+
 	// --- optional user_id ---
-	var userID *uuid.UUID
 	if v := c.Query("user_id"); v != "" {
 		u, err := uuid.Parse(v)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user_id"})
-			return
+			badRequest(c, "invalid user_id")
+			return f, false
 		}
-		userID = &u
+		f.UserID = &u
+	}
+
+	// --- optional service_id ---
+	if v := c.Query("service_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			badRequest(c, "invalid service_id")
+			return f, false
+		}
+		f.ServiceID = &id
 	}
 
 	// --- optional service_name ---
-	var serviceName *string
 	if v := c.Query("service_name"); v != "" {
-		serviceName = &v
+		f.ServiceName = &v
 	}
 
-	total, err := h.svc.Total(c.Request.Context(), service.TotalFilter{
-		UserID:      userID,
-		ServiceName: serviceName,
-		From:        from,
-		To:          to,
-	})
+	// --- optional target currency ---
+	f.Currency = domain.NormalizeCurrency(c.Query("currency"))
+	if f.Currency != "" && !domain.IsCurrency(f.Currency) {
+		badRequest(c, "invalid currency")
+		return f, false
+	}
+
+	// --- optional mode ---
+	f.Mode = service.TotalMode(c.DefaultQuery("mode", string(service.TotalModeBilled)))
+	if !f.Mode.Valid() {
+		badRequest(c, "invalid mode")
+		return f, false
+	}
+
+	return f, true
+}
+
+// parsePeriodBound accepts a day (YYYY-MM-DD) or a month (YYYY-MM). A month
+// resolves to its first day, or to its last day when end is set.
+func parsePeriodBound(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.DateOnly, v); err == nil {
+		return t, nil
+	}
+
+	t, err := time.Parse("2006-01", v)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "internal error"})
-		return
+		return time.Time{}, err
+	}
+	if end {
+		t = t.AddDate(0, 1, -1)
 	}
+	return t, nil
+}
 
-	c.JSON(http.StatusOK, TotalResponse{
-		Total: total,
-	})
+func toBreakdownResponse(b service.BreakdownResult) BreakdownResponse {
+	resp := BreakdownResponse{
+		GroupBy:    make([]string, 0, len(b.GroupBy)),
+		Groups:     make([]BreakdownGroupResponse, 0, len(b.Groups)),
+		GrandTotal: toTotalResponse(b.GrandTotal),
+	}
+	for _, g := range b.GroupBy {
+		resp.GroupBy = append(resp.GroupBy, string(g))
+	}
+	for _, g := range b.Groups {
+		gr := BreakdownGroupResponse{
+			ServiceName:   g.ServiceName,
+			UserID:        g.UserID,
+			TotalResponse: toTotalResponse(g.TotalResult),
+		}
+		if g.Month != nil {
+			gr.Month = g.Month.Format("2006-01")
+		}
+		resp.Groups = append(resp.Groups, gr)
+	}
+	return resp
+}
+
+func toTotalResponse(t service.TotalResult) TotalResponse {
+	resp := TotalResponse{
+		Total:     t.Total,
+		Gross:     t.Gross,
+		Discount:  t.Discount,
+		Currency:  t.Currency,
+		Subtotals: make([]MoneyResponse, 0, len(t.Subtotals)),
+	}
+	for _, m := range t.Subtotals {
+		resp.Subtotals = append(resp.Subtotals, MoneyResponse{
+			Amount:   m.Amount,
+			Gross:    m.Gross,
+			Discount: m.Discount,
+			Currency: m.Currency,
+		})
+	}
+	return resp
 }
@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	defaultTrialDays = 7
+	maxTrialDays     = 365
+)
+
+// TrialsEnding reports trials about to convert
+// @Summary      Trials ending soon
+// @Description  Trials that end between today and days from now and then turn into paid
+// @Description  subscriptions, soonest first, with the first charge they will cause
+// @Tags         subscriptions
+// @Produce      json
+// @Param        days query int false "Look-ahead in days (default 7, max 365)"
+// @Param        user_id query string false "User ID"
+// @Success      200 {object} TrialsEndingResponse
+// @Failure      400 {object} Problem
+// @Failure      500 {object} Problem
+// @Router       /subscriptions/trials/ending [get]
+func (h *SubscriptionHandler) TrialsEnding(c *gin.Context) {
+	days := defaultTrialDays
+	if v := c.Query("days"); v != "" {
+		n, err := parseTrialDays(v)
+		if err != nil {
+			badRequest(c, "invalid days")
+			return
+		}
+		days = n
+	}
+
+	var userID *uuid.UUID
+	if v := c.Query("user_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			badRequest(c, "invalid user_id")
+			return
+		}
+		userID = &id
+	}
+
+	subs, err := h.svc.TrialsEnding(c.Request.Context(), userID, days)
+	if err != nil {
+		handleError(c, err)
+		return
+	}
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	resp := TrialsEndingResponse{
+		Days:  days,
+		Items: make([]TrialEndingResponse, 0, len(subs)),
+	}
+	for i := range subs {
+		s := &subs[i]
+		anchor := s.BillingAnchor()
+		resp.Items = append(resp.Items, TrialEndingResponse{
+			Subscription:     toResponse(s),
+			DaysLeft:         int(s.TrialEndDate.Sub(today).Hours() / 24),
+			FirstChargeDate:  anchor,
+			FirstChargePrice: s.PriceOn(anchor),
+		})
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
+
+func parseTrialDays(v string) (int, error) {
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, err
+	}
+	if n < 0 || n > maxTrialDays {
+		return 0, errors.New("out of range")
+	}
+	return n, nil
+}
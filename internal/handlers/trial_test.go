@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/service"
+)
+
+func TestTrialEndDate(t *testing.T) {
+	day := func(m time.Month, d int) *time.Time { t := time.Date(2025, m, d, 0, 0, 0, 0, time.UTC); return &t }
+	start := *day(time.January, 10)
+
+	tests := []struct {
+		name string
+		end  *time.Time
+		days int
+		want *time.Time
+	}{
+		{"no trial", nil, 0, nil},
+		{"last day given", day(time.January, 24), 0, day(time.January, 24)},
+		{"length given", nil, 14, day(time.January, 23)},
+		{"one day is the start date", nil, 1, &start},
+		{"length wins over the last day", day(time.February, 28), 14, day(time.January, 23)},
+		{"across a month", nil, 30, day(time.February, 8)},
+	}
+
+	for _, tt := range tests {
+		got := trialEndDate(start, tt.end, tt.days)
+		if (got == nil) != (tt.want == nil) || got != nil && !got.Equal(*tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseTrialDays(t *testing.T) {
+	tests := []struct {
+		v      string
+		want   int
+		wantOK bool
+	}{
+		{"0", 0, true},
+		{"7", 7, true},
+		{"365", 365, true},
+		{"366", 0, false},
+		{"-1", 0, false},
+		{"7d", 0, false},
+		{"", 0, false},
+	}
+
+	for _, tt := range tests {
+		got, err := parseTrialDays(tt.v)
+		if got != tt.want || (err == nil) != tt.wantOK {
+			t.Errorf("parseTrialDays(%q): got %d %v, want %d ok=%v", tt.v, got, err, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// trialsService returns subs from TrialsEnding, recording the look-ahead.
+type trialsService struct {
+	service.SubscriptionService
+	subs   []domain.Subscription
+	within int
+}
+
+func (s *trialsService) TrialsEnding(ctx context.Context, userID *uuid.UUID, within int) ([]domain.Subscription, error) {
+	s.within = within
+	return s.subs, nil
+}
+
+func TestTrialsEnding(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	now := time.Now().UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	trial := func(daysLeft int) domain.Subscription {
+		end := today.AddDate(0, 0, daysLeft)
+		return domain.Subscription{
+			ID: uuid.New(), Price: 100, Currency: "RUB", Billing: domain.MonthlyBilling,
+			StartDate: today.AddDate(0, 0, -20), TrialEndDate: &end,
+		}
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantDays   int
+	}{
+		{"default", "", http.StatusOK, defaultTrialDays},
+		{"today only", "?days=0", http.StatusOK, 0},
+		{"longest", "?days=365", http.StatusOK, 365},
+		{"too long", "?days=366", http.StatusBadRequest, 0},
+		{"negative", "?days=-1", http.StatusBadRequest, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &trialsService{subs: []domain.Subscription{trial(0), trial(tt.wantDays)}}
+			r := gin.New()
+			r.GET("/trials", NewSubscriptionHandler(svc).TrialsEnding)
+
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trials"+tt.query, nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("got status %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if rec.Code != http.StatusOK {
+				return
+			}
+
+			var resp TrialsEndingResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if svc.within != tt.wantDays || resp.Days != tt.wantDays {
+				t.Errorf("got look-ahead %d, answered %d, want %d", svc.within, resp.Days, tt.wantDays)
+			}
+			// A trial ending today has no days left; one on the last day
+			// of the window has all of them.
+			if len(resp.Items) != 2 || resp.Items[0].DaysLeft != 0 || resp.Items[1].DaysLeft != tt.wantDays {
+				t.Fatalf("got %+v, want days left 0 and %d", resp.Items, tt.wantDays)
+			}
+			if want := today.AddDate(0, 0, 1); !resp.Items[0].FirstChargeDate.Equal(want) {
+				t.Errorf("trial ending today: got the first charge on %s, want %s",
+					resp.Items[0].FirstChargeDate.Format(time.DateOnly), want.Format(time.DateOnly))
+			}
+		})
+	}
+}
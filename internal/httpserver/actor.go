@@ -0,0 +1,40 @@
+package httpserver
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/reqctx"
+)
+
+// ActorHeader names the caller and RoleHeader its role. The API has no
+// authentication of its own, so it trusts the gateway in front of it to
+// set both headers.
+const (
+	ActorHeader = "X-Actor"
+	RoleHeader  = "X-Actor-Role"
+)
+
+// RoleAdmin may see deleted subscriptions.
+const RoleAdmin = "admin"
+
+// Actor stores the ActorHeader and RoleHeader values in the request
+// context for the audit log and the checks of IsAdmin.
+func Actor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if actor := c.GetHeader(ActorHeader); actor != "" && len(actor) <= 128 {
+			ctx = reqctx.WithActor(ctx, actor)
+		}
+		if role := c.GetHeader(RoleHeader); role != "" {
+			ctx = reqctx.WithRole(ctx, role)
+		}
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
+
+// IsAdmin reports whether the caller identified by Actor has RoleAdmin.
+func IsAdmin(c *gin.Context) bool {
+	return reqctx.Actor(c.Request.Context()) != "" && reqctx.Role(c.Request.Context()) == RoleAdmin
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor points at the last row of a page; the next page starts strictly
+// after it in the order it was taken from. Values hold the row's sort keys
+// in their PostgreSQL text form, so they compare exactly as stored.
+type Cursor struct {
+	Sort   string    `json:"s"`
+	Values []string  `json:"v"`
+	ID     uuid.UUID `json:"i"`
+}
+
+// NewCursor builds the cursor that continues after s in the given order.
+func NewCursor(keys []SortKey, s *domain.Subscription) Cursor {
+	keys = sortOrDefault(keys)
+
+	c := Cursor{Sort: sortSignature(keys), ID: s.ID}
+	for _, k := range keys {
+		c.Values = append(c.Values, sortColumns[k.Field].value(s))
+	}
+	return c
+}
+
+// Encode returns the opaque form handed out to clients.
+func (c Cursor) Encode() string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func DecodeCursor(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == uuid.Nil || !c.valid() {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
+
+// valid reports whether every value of c fits the column of its sort key,
+// so that a tampered cursor is rejected here rather than failing the
+// casts of keysetCondition.
+func (c *Cursor) valid() bool {
+	keys := strings.Split(c.Sort, ",")
+	if len(keys) != len(c.Values) {
+		return false
+	}
+	for i, k := range keys {
+		field, _, _ := strings.Cut(k, ":")
+		col, ok := sortColumns[field]
+		if !ok || !validSortValue(col.typ, c.Values[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestDecodeCursor(t *testing.T) {
+	end := time.Date(2025, time.March, 31, 0, 0, 0, 0, time.UTC)
+	sub := &domain.Subscription{
+		ID:          uuid.New(),
+		ServiceName: "Netflix",
+		Price:       500,
+		StartDate:   time.Date(2025, time.January, 31, 0, 0, 0, 0, time.UTC),
+		EndDate:     &end,
+		CreatedAt:   time.Date(2025, time.January, 2, 15, 4, 5, 123456789, time.UTC),
+	}
+	keys := []SortKey{
+		{Field: "price", Desc: true},
+		{Field: "start_date"},
+		{Field: "end_date"},
+		{Field: "trial_end_date"},
+		{Field: "service_name"},
+		{Field: "created_at"},
+	}
+
+	if _, err := DecodeCursor(NewCursor(keys, sub).Encode()); err != nil {
+		t.Fatalf("valid cursor: %v", err)
+	}
+
+	tampered := func(field, value string) string {
+		c := NewCursor(keys, sub)
+		for i, k := range keys {
+			if k.Field == field {
+				c.Values[i] = value
+			}
+		}
+		return c.Encode()
+	}
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"not base64", "%%%"},
+		{"not json", base64.RawURLEncoding.EncodeToString([]byte("{"))},
+		{"no id", Cursor{Sort: "price:asc", Values: []string{"1"}}.Encode()},
+		{"missing value", Cursor{Sort: "price:asc,start_date:asc", Values: []string{"1"}, ID: sub.ID}.Encode()},
+		{"unknown field", Cursor{Sort: "user_id:asc", Values: []string{"x"}, ID: sub.ID}.Encode()},
+		{"integer", tampered("price", "1e3")},
+		{"integer overflow", tampered("price", "9999999999")},
+		{"date", tampered("start_date", "2025-02-30")},
+		{"year zero", tampered("end_date", "0000-01-01")},
+		{"timestamp", tampered("created_at", "yesterday")},
+		{"text with NUL", tampered("service_name", "Net\x00flix")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeCursor(tt.cursor); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("got %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+}
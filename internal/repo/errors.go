@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// SQLSTATE codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgUniqueViolation     = "23505"
+	pgForeignKeyViolation = "23503"
+	pgCheckViolation      = "23514"
+	pgNotNullViolation    = "23502"
+
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// checkFields maps CHECK constraint names to the API field they guard.
+var checkFields = map[string]domain.FieldError{
+	"subscriptions_price_check":            {Field: "price", Code: "must_be_positive", Message: "must be greater than 0"},
+	"subscriptions_check":                  {Field: "end_date", Code: "before_start_date", Message: "must not be before start_date"},
+	"subscriptions_currency_check":         {Field: "currency", Code: "invalid_currency", Message: "must be an ISO 4217 code"},
+	"subscriptions_billing_unit_check":     {Field: "billing_unit", Code: "invalid_billing_unit", Message: "must be one of week, month, year"},
+	"subscriptions_billing_count_check":    {Field: "billing_count", Code: "must_be_positive", Message: "must be greater than 0"},
+	"exchange_rates_rate_check":            {Field: "rate", Code: "must_be_positive", Message: "must be greater than 0"},
+	"exchange_rates_check":                 {Field: "quote", Code: "same_currency", Message: "must differ from base"},
+	"services_default_price_check":         {Field: "default_price", Code: "must_be_positive", Message: "must be greater than 0"},
+	"services_default_billing_unit_check":  {Field: "default_billing_unit", Code: "invalid_billing_unit", Message: "must be one of week, month, year"},
+	"services_default_billing_count_check": {Field: "default_billing_count", Code: "must_be_positive", Message: "must be greater than 0"},
+	"service_plans_price_check":            {Field: "price", Code: "must_be_positive", Message: "must be greater than 0"},
+	"service_plans_currency_check":         {Field: "currency", Code: "invalid_currency", Message: "must be an ISO 4217 code"},
+	"service_plans_billing_unit_check":     {Field: "billing_unit", Code: "invalid_billing_unit", Message: "must be one of week, month, year"},
+	"service_plans_billing_count_check":    {Field: "billing_count", Code: "must_be_positive", Message: "must be greater than 0"},
+}
+
+// mapError translates driver errors into domain errors.
+func mapError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation, pgForeignKeyViolation:
+		return fmt.Errorf("%w: %s", domain.ErrConflict, pgErr.ConstraintName)
+
+	case pgCheckViolation:
+		if fe, ok := checkFields[pgErr.ConstraintName]; ok {
+			return domain.NewValidationError(fe)
+		}
+		return domain.NewValidationError(domain.FieldError{
+			Field:   pgErr.ConstraintName,
+			Code:    "check_violation",
+			Message: pgErr.Message,
+		})
+
+	case pgNotNullViolation:
+		return domain.NewValidationError(domain.FieldError{
+			Field:   pgErr.ColumnName,
+			Code:    "required",
+			Message: "is required",
+		})
+	}
+
+	return err
+}
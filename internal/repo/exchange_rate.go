@@ -0,0 +1,21 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type ExchangeRateRepository interface {
+	Upsert(ctx context.Context, r *domain.ExchangeRate) error
+	// UpsertMany stores all rates in one transaction.
+	UpsertMany(ctx context.Context, rates []domain.ExchangeRate) error
+	// Delete removes the rate for date, or the whole pair history when
+	// date is nil.
+	Delete(ctx context.Context, base, quote string, date *time.Time) error
+	List(ctx context.Context, f ExchangeRateFilter) ([]domain.ExchangeRate, error)
+	// History returns every rate of the pair effective on or before until,
+	// ordered by date.
+	History(ctx context.Context, base, quote string, until time.Time) ([]domain.ExchangeRate, error)
+}
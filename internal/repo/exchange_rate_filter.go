@@ -0,0 +1,10 @@
+package repo
+
+import "time"
+
+type ExchangeRateFilter struct {
+	Base  *string
+	Quote *string
+	From  *time.Time
+	To    *time.Time
+}
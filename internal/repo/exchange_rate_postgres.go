@@ -0,0 +1,158 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+const upsertExchangeRateQuery = `
+	INSERT INTO exchange_rates (base_currency, quote_currency, rate_date, rate)
+	VALUES ($1, $2, $3, $4)
+	ON CONFLICT (base_currency, quote_currency, rate_date)
+	DO UPDATE SET rate = EXCLUDED.rate, updated_at = now()
+	RETURNING updated_at
+`
+
+type ExchangeRatePostgres struct {
+	db *sql.DB
+}
+
+func NewExchangeRatePostgres(db *sql.DB) *ExchangeRatePostgres {
+	return &ExchangeRatePostgres{db: db}
+}
+
+func (r *ExchangeRatePostgres) Upsert(ctx context.Context, er *domain.ExchangeRate) error {
+	err := conn(ctx, r.db).QueryRowContext(
+		ctx,
+		upsertExchangeRateQuery,
+		er.Base,
+		er.Quote,
+		er.Date,
+		er.Rate,
+	).Scan(&er.UpdatedAt)
+
+	return mapError(err)
+}
+
+func (r *ExchangeRatePostgres) UpsertMany(ctx context.Context, rates []domain.ExchangeRate) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		stmt, err := conn(ctx, r.db).PrepareContext(ctx, upsertExchangeRateQuery)
+		if err != nil {
+			return mapError(err)
+		}
+		defer stmt.Close()
+
+		for i := range rates {
+			er := &rates[i]
+			if err := stmt.QueryRowContext(ctx, er.Base, er.Quote, er.Date, er.Rate).Scan(&er.UpdatedAt); err != nil {
+				return fmt.Errorf("rate %s/%s %s: %w", er.Base, er.Quote, er.Date.Format(time.DateOnly), mapError(err))
+			}
+		}
+
+		return nil
+	})
+}
+
+func (r *ExchangeRatePostgres) Delete(ctx context.Context, base, quote string, date *time.Time) error {
+	query := `DELETE FROM exchange_rates WHERE base_currency = $1 AND quote_currency = $2`
+	args := []any{base, quote}
+
+	if date != nil {
+		query += ` AND rate_date = $3`
+		args = append(args, *date)
+	}
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ExchangeRatePostgres) List(ctx context.Context, f ExchangeRateFilter) ([]domain.ExchangeRate, error) {
+	var (
+		conds []string
+		args  []any
+		argN  = 1
+	)
+
+	if f.Base != nil {
+		conds = append(conds, fmt.Sprintf("base_currency = $%d", argN))
+		args = append(args, *f.Base)
+		argN++
+	}
+
+	if f.Quote != nil {
+		conds = append(conds, fmt.Sprintf("quote_currency = $%d", argN))
+		args = append(args, *f.Quote)
+		argN++
+	}
+
+	if f.From != nil {
+		conds = append(conds, fmt.Sprintf("rate_date >= $%d", argN))
+		args = append(args, *f.From)
+		argN++
+	}
+
+	if f.To != nil {
+		conds = append(conds, fmt.Sprintf("rate_date <= $%d", argN))
+		args = append(args, *f.To)
+	}
+
+	query := `
+		SELECT base_currency, quote_currency, rate_date, rate, updated_at
+		FROM exchange_rates
+	`
+
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query += " ORDER BY base_currency, quote_currency, rate_date"
+
+	return r.query(ctx, query, args...)
+}
+
+func (r *ExchangeRatePostgres) History(ctx context.Context, base, quote string, until time.Time) ([]domain.ExchangeRate, error) {
+	query := `
+		SELECT base_currency, quote_currency, rate_date, rate, updated_at
+		FROM exchange_rates
+		WHERE base_currency = $1 AND quote_currency = $2 AND rate_date <= $3
+		ORDER BY rate_date
+	`
+
+	return r.query(ctx, query, base, quote, until)
+}
+
+func (r *ExchangeRatePostgres) query(ctx context.Context, query string, args ...any) ([]domain.ExchangeRate, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var res []domain.ExchangeRate
+	for rows.Next() {
+		var er domain.ExchangeRate
+		if err := rows.Scan(&er.Base, &er.Quote, &er.Date, &er.Rate, &er.UpdatedAt); err != nil {
+			return nil, err
+		}
+		res = append(res, er)
+	}
+
+	return res, rows.Err()
+}
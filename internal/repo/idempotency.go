@@ -0,0 +1,22 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type IdempotencyRepository interface {
+	// Claim records key for a new request, held for lease, unless an
+	// unexpired record exists that is completed or still within its
+	// lease; that record is returned with claimed false. A record that
+	// vanishes while it is read back is reported as still in progress.
+	Claim(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (rec *domain.IdempotentRequest, claimed bool, err error)
+	// Complete stores the response of a claimed request and ends its
+	// lease.
+	Complete(ctx context.Context, rec *domain.IdempotentRequest) error
+	// Release forgets a claimed request so that it can be retried.
+	Release(ctx context.Context, key string) error
+	DeleteExpired(ctx context.Context) (int64, error)
+}
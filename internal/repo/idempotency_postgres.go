@@ -0,0 +1,129 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type IdempotencyPostgres struct {
+	db *sql.DB
+}
+
+func NewIdempotencyPostgres(db *sql.DB) *IdempotencyPostgres {
+	return &IdempotencyPostgres{db: db}
+}
+
+// claimAttempts bounds how often Claim retries when the record it
+// conflicted with is released before it can be read.
+const claimAttempts = 3
+
+func (r *IdempotencyPostgres) Claim(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*domain.IdempotentRequest, bool, error) {
+	for attempt := 1; ; attempt++ {
+		rec, claimed, err := r.claim(ctx, key, requestHash, ttl, lease)
+		if !errors.Is(err, domain.ErrNotFound) {
+			return rec, claimed, err
+		}
+		if attempt == claimAttempts {
+			// The key keeps changing hands; the client may retry.
+			return &domain.IdempotentRequest{Key: key, RequestHash: requestHash}, false, nil
+		}
+	}
+}
+
+func (r *IdempotencyPostgres) claim(ctx context.Context, key, requestHash string, ttl, lease time.Duration) (*domain.IdempotentRequest, bool, error) {
+	// An expired record, or one whose request let its lease lapse without
+	// an answer, is taken over as if it did not exist.
+	query := `
+		INSERT INTO idempotency_keys (key, request_hash, expires_at, locked_until)
+		VALUES ($1, $2, now() + make_interval(secs => $3), now() + make_interval(secs => $4))
+		ON CONFLICT (key) DO UPDATE
+		SET request_hash = EXCLUDED.request_hash,
+		    status_code = NULL,
+		    headers = NULL,
+		    body = NULL,
+		    created_at = now(),
+		    expires_at = EXCLUDED.expires_at,
+		    locked_until = EXCLUDED.locked_until
+		WHERE idempotency_keys.expires_at <= now()
+		   OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= now())
+		RETURNING expires_at
+	`
+
+	rec := &domain.IdempotentRequest{Key: key, RequestHash: requestHash}
+
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, key, requestHash, ttl.Seconds(), lease.Seconds()).Scan(&rec.ExpiresAt)
+	if err == nil {
+		return rec, true, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, mapError(err)
+	}
+
+	// The conflicting record may be released before it is read, which
+	// mapError reports as domain.ErrNotFound for Claim to retry.
+	var (
+		status  sql.NullInt64
+		headers []byte
+	)
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT request_hash, status_code, headers, body, expires_at
+		FROM idempotency_keys
+		WHERE key = $1
+	`, key).Scan(&rec.RequestHash, &status, &headers, &rec.Body, &rec.ExpiresAt)
+	if err != nil {
+		return nil, false, mapError(err)
+	}
+
+	rec.StatusCode = int(status.Int64)
+	if headers != nil {
+		if err := json.Unmarshal(headers, &rec.Header); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return rec, false, nil
+}
+
+func (r *IdempotencyPostgres) Complete(ctx context.Context, rec *domain.IdempotentRequest) error {
+	headers, err := json.Marshal(rec.Header)
+	if err != nil {
+		return err
+	}
+
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE idempotency_keys
+		SET status_code = $2, headers = $3, body = $4, locked_until = NULL
+		WHERE key = $1 AND request_hash = $5 AND status_code IS NULL
+	`, rec.Key, rec.StatusCode, headers, rec.Body, rec.RequestHash)
+	if err != nil {
+		return mapError(err)
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *IdempotencyPostgres) Release(ctx context.Context, key string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE key = $1 AND status_code IS NULL`, key)
+	return mapError(err)
+}
+
+func (r *IdempotencyPostgres) DeleteExpired(ctx context.Context) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= now()`)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return res.RowsAffected()
+}
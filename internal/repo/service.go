@@ -0,0 +1,37 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// ServiceRepository stores the service catalog. Every method returning
+// services fills in their Aliases and Plans. Names and aliases are
+// unique across the catalog by domain.ServiceKey; storing one that is
+// already taken fails with domain.ErrConflict.
+type ServiceRepository interface {
+	Create(ctx context.Context, s *domain.Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	// FindByName returns the service known by name, canonical or alias.
+	FindByName(ctx context.Context, name string) (*domain.Service, error)
+	// Update replaces the fields and aliases of s; the subscriptions of
+	// the service are left alone.
+	Update(ctx context.Context, s *domain.Service) error
+	// Delete fails with domain.ErrConflict while subscriptions that are
+	// not deleted refer to the service; deleted ones are unlinked from it
+	// and its plans.
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, f ServiceFilter) ([]domain.Service, error)
+
+	// Plan names are unique within a service, ignoring case. UpdatePlan
+	// and DeletePlan fail with domain.ErrNotFound when p.ServiceID has no
+	// such plan, and with domain.ErrConflict while subscriptions refer to
+	// the plan: UpdatePlan when it changes the currency, DeletePlan always.
+	CreatePlan(ctx context.Context, p *domain.Plan) error
+	GetPlan(ctx context.Context, id uuid.UUID) (*domain.Plan, error)
+	UpdatePlan(ctx context.Context, p *domain.Plan) error
+	DeletePlan(ctx context.Context, serviceID, id uuid.UUID) error
+}
@@ -0,0 +1,8 @@
+package repo
+
+type ServiceFilter struct {
+	Category *string
+	// Search keeps services with a name or alias starting with it,
+	// compared by domain.ServiceKey.
+	Search *string
+}
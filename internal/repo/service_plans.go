@@ -0,0 +1,156 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+const planColumns = `
+	id, service_id, name, price, currency, billing_unit, billing_count, created_at, updated_at
+`
+
+func scanPlan(row rowScanner, p *domain.Plan) error {
+	return row.Scan(
+		&p.ID,
+		&p.ServiceID,
+		&p.Name,
+		&p.Price,
+		&p.Currency,
+		&p.Billing.Unit,
+		&p.Billing.Count,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+}
+
+func (r *ServicePostgres) CreatePlan(ctx context.Context, p *domain.Plan) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO service_plans
+		    (service_id, name, price, currency, billing_unit, billing_count)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at, updated_at
+	`,
+		p.ServiceID,
+		p.Name,
+		p.Price,
+		p.Currency,
+		p.Billing.Unit,
+		p.Billing.Count,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+
+	return planError(err, p.Name)
+}
+
+func (r *ServicePostgres) GetPlan(ctx context.Context, id uuid.UUID) (*domain.Plan, error) {
+	var p domain.Plan
+	row := conn(ctx, r.db).QueryRowContext(ctx, `SELECT `+planColumns+` FROM service_plans WHERE id = $1`, id)
+	if err := scanPlan(row, &p); err != nil {
+		return nil, mapError(err)
+	}
+	return &p, nil
+}
+
+func (r *ServicePostgres) UpdatePlan(ctx context.Context, p *domain.Plan) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		return r.updatePlan(ctx, p)
+	})
+}
+
+func (r *ServicePostgres) updatePlan(ctx context.Context, p *domain.Plan) error {
+	db := conn(ctx, r.db)
+
+	// Subscriptions are billed in the currency of their plan, so it is
+	// fixed once any refers to the plan, deleted ones included.
+	var inUse bool
+	err := db.QueryRowContext(ctx, `
+		SELECT currency <> $3 AND (
+		           EXISTS (SELECT 1 FROM subscriptions WHERE plan_id = $1)
+		        OR EXISTS (SELECT 1 FROM subscription_plan_changes WHERE plan_id = $1))
+		FROM service_plans
+		WHERE id = $1 AND service_id = $2
+		FOR UPDATE
+	`, p.ID, p.ServiceID, p.Currency).Scan(&inUse)
+	if err != nil {
+		return mapError(err)
+	}
+	if inUse {
+		return fmt.Errorf("%w: the currency of a plan cannot change while subscriptions refer to it", domain.ErrConflict)
+	}
+
+	err = db.QueryRowContext(ctx, `
+		UPDATE service_plans
+		SET name = $1,
+		    price = $2,
+		    currency = $3,
+		    billing_unit = $4,
+		    billing_count = $5,
+		    updated_at = now()
+		WHERE id = $6 AND service_id = $7
+		RETURNING created_at, updated_at
+	`,
+		p.Name,
+		p.Price,
+		p.Currency,
+		p.Billing.Unit,
+		p.Billing.Count,
+		p.ID,
+		p.ServiceID,
+	).Scan(&p.CreatedAt, &p.UpdatedAt)
+
+	return planError(err, p.Name)
+}
+
+func (r *ServicePostgres) DeletePlan(ctx context.Context, serviceID, id uuid.UUID) error {
+	return deleteReferenced(ctx, conn(ctx, r.db), "plan", `
+		DELETE FROM service_plans WHERE id = $1 AND service_id = $2
+	`, id, serviceID)
+}
+
+// planError maps err like mapError, naming the plan a duplicate name
+// clashes with.
+func planError(err error, name string) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+		return fmt.Errorf("%w: the service already has a plan named %q", domain.ErrConflict, name)
+	}
+	return mapError(err)
+}
+
+// loadPlans fills in the Plans of services with one query.
+func loadPlans(ctx context.Context, db dbtx, services []*domain.Service) error {
+	byID := make(map[uuid.UUID]*domain.Service, len(services))
+	ids := make([]uuid.UUID, 0, len(services))
+	for _, s := range services {
+		s.Plans = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT `+planColumns+`
+		FROM service_plans
+		WHERE service_id = ANY($1)
+		ORDER BY service_id, lower(name)
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p domain.Plan
+		if err := scanPlan(rows, &p); err != nil {
+			return err
+		}
+		s := byID[p.ServiceID]
+		s.Plans = append(s.Plans, p)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,314 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+const serviceColumns = `
+	id, name, COALESCE(category, ''),
+	default_price, COALESCE(default_currency, ''), default_billing_unit, default_billing_count,
+	created_at, updated_at
+`
+
+func scanService(row rowScanner, s *domain.Service) error {
+	return row.Scan(
+		&s.ID,
+		&s.Name,
+		&s.Category,
+		&s.DefaultPrice,
+		&s.DefaultCurrency,
+		&s.DefaultBilling.Unit,
+		&s.DefaultBilling.Count,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+	)
+}
+
+type ServicePostgres struct {
+	db *sql.DB
+}
+
+func NewServicePostgres(db *sql.DB) *ServicePostgres {
+	return &ServicePostgres{db: db}
+}
+
+func (r *ServicePostgres) Create(ctx context.Context, s *domain.Service) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		db := conn(ctx, r.db)
+
+		err := db.QueryRowContext(ctx, `
+			INSERT INTO services
+			    (name, category, default_price, default_currency,
+			     default_billing_unit, default_billing_count)
+			VALUES ($1, NULLIF($2, ''), $3, NULLIF($4, ''), $5, $6)
+			RETURNING id, created_at, updated_at
+		`,
+			s.Name,
+			s.Category,
+			s.DefaultPrice,
+			s.DefaultCurrency,
+			s.DefaultBilling.Unit,
+			s.DefaultBilling.Count,
+		).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return mapError(err)
+		}
+
+		return insertAliases(ctx, db, s)
+	})
+}
+
+func (r *ServicePostgres) GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	return r.get(ctx, `id = $1`, id)
+}
+
+func (r *ServicePostgres) FindByName(ctx context.Context, name string) (*domain.Service, error) {
+	return r.get(ctx, `id = (SELECT service_id FROM service_aliases WHERE key = $1)`, domain.ServiceKey(name))
+}
+
+func (r *ServicePostgres) get(ctx context.Context, cond string, args ...any) (*domain.Service, error) {
+	db := conn(ctx, r.db)
+
+	var s domain.Service
+	row := db.QueryRowContext(ctx, `SELECT `+serviceColumns+` FROM services WHERE `+cond, args...)
+	if err := scanService(row, &s); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &s, loadCatalogDetails(ctx, db, &s)
+}
+
+func (r *ServicePostgres) Update(ctx context.Context, s *domain.Service) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		db := conn(ctx, r.db)
+
+		err := db.QueryRowContext(ctx, `
+			UPDATE services
+			SET name = $1,
+			    category = NULLIF($2, ''),
+			    default_price = $3,
+			    default_currency = NULLIF($4, ''),
+			    default_billing_unit = $5,
+			    default_billing_count = $6,
+			    updated_at = now()
+			WHERE id = $7
+			RETURNING created_at, updated_at
+		`,
+			s.Name,
+			s.Category,
+			s.DefaultPrice,
+			s.DefaultCurrency,
+			s.DefaultBilling.Unit,
+			s.DefaultBilling.Count,
+			s.ID,
+		).Scan(&s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return mapError(err)
+		}
+
+		if _, err := db.ExecContext(ctx, `DELETE FROM service_aliases WHERE service_id = $1`, s.ID); err != nil {
+			return mapError(err)
+		}
+		return insertAliases(ctx, db, s)
+	})
+}
+
+func (r *ServicePostgres) Delete(ctx context.Context, id uuid.UUID) error {
+	return withinTx(ctx, r.db, func(ctx context.Context) error {
+		db := conn(ctx, r.db)
+
+		// Deleted subscriptions keep their name, and their plan changes
+		// what the plans were, but let go of the service and its plans.
+		_, err := db.ExecContext(ctx, `
+			UPDATE subscription_plan_changes pc
+			SET plan_id = NULL
+			FROM subscriptions s, service_plans p
+			WHERE s.id = pc.subscription_id
+			  AND s.deleted_at IS NOT NULL
+			  AND p.id = pc.plan_id
+			  AND p.service_id = $1
+		`, id)
+		if err != nil {
+			return mapError(err)
+		}
+
+		_, err = db.ExecContext(ctx, `
+			UPDATE subscriptions
+			SET service_id = NULL,
+			    plan_id = NULL,
+			    version = version + 1,
+			    updated_at = now()
+			WHERE service_id = $1 AND deleted_at IS NOT NULL
+		`, id)
+		if err != nil {
+			return mapError(err)
+		}
+
+		return deleteReferenced(ctx, db, "service", `DELETE FROM services WHERE id = $1`, id)
+	})
+}
+
+// deleteReferenced is execOne for a DELETE of a catalog entry that
+// subscriptions may still refer to, which fails with domain.ErrConflict
+// naming what. The foreign key violation is told apart before mapError
+// turns it into a bare conflict.
+func deleteReferenced(ctx context.Context, db dbtx, what, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == pgForeignKeyViolation {
+		return fmt.Errorf("%w: %s is referenced by subscriptions", domain.ErrConflict, what)
+	}
+	if err != nil {
+		return mapError(err)
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+func (r *ServicePostgres) List(ctx context.Context, f ServiceFilter) ([]domain.Service, error) {
+	var (
+		conds []string
+		args  []any
+		argN  = 1
+	)
+
+	if f.Category != nil {
+		conds = append(conds, fmt.Sprintf("category = $%d", argN))
+		args = append(args, *f.Category)
+		argN++
+	}
+
+	if f.Search != nil {
+		conds = append(conds, fmt.Sprintf(`id IN (
+			SELECT service_id FROM service_aliases WHERE key LIKE $%d ESCAPE '\'
+		)`, argN))
+		args = append(args, likeEscaper.Replace(domain.ServiceKey(*f.Search))+"%")
+	}
+
+	query := `SELECT ` + serviceColumns + ` FROM services`
+
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	query += " ORDER BY lower(name), id"
+
+	db := conn(ctx, r.db)
+
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var res []domain.Service
+	for rows.Next() {
+		var s domain.Service
+		if err := scanService(rows, &s); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ptrs := make([]*domain.Service, len(res))
+	for i := range res {
+		ptrs[i] = &res[i]
+	}
+
+	return res, loadCatalogDetails(ctx, db, ptrs...)
+}
+
+// insertAliases stores the name and every alias of s under their keys.
+func insertAliases(ctx context.Context, db dbtx, s *domain.Service) error {
+	stmt, err := db.PrepareContext(ctx, `
+		INSERT INTO service_aliases (key, alias, service_id, canonical)
+		VALUES ($1, $2, $3, $4)
+	`)
+	if err != nil {
+		return mapError(err)
+	}
+	defer stmt.Close()
+
+	names := append([]string{s.Name}, s.Aliases...)
+	for i, name := range names {
+		_, err := stmt.ExecContext(ctx, domain.ServiceKey(name), name, s.ID, i == 0)
+
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation {
+			return fmt.Errorf("%w: name %q is already taken", domain.ErrConflict, name)
+		}
+		if err != nil {
+			return mapError(err)
+		}
+	}
+
+	return nil
+}
+
+// loadCatalogDetails fills in what services keep in child tables.
+func loadCatalogDetails(ctx context.Context, db dbtx, services ...*domain.Service) error {
+	if len(services) == 0 {
+		return nil
+	}
+	if err := loadAliases(ctx, db, services); err != nil {
+		return err
+	}
+	return loadPlans(ctx, db, services)
+}
+
+// loadAliases fills in the Aliases of services with one query.
+func loadAliases(ctx context.Context, db dbtx, services []*domain.Service) error {
+	byID := make(map[uuid.UUID]*domain.Service, len(services))
+	ids := make([]uuid.UUID, 0, len(services))
+	for _, s := range services {
+		s.Aliases = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT service_id, alias
+		FROM service_aliases
+		WHERE service_id = ANY($1) AND NOT canonical
+		ORDER BY service_id, key
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id    uuid.UUID
+			alias string
+		)
+		if err := rows.Scan(&id, &alias); err != nil {
+			return err
+		}
+		s := byID[id]
+		s.Aliases = append(s.Aliases, alias)
+	}
+
+	return rows.Err()
+}
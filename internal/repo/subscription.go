@@ -2,17 +2,66 @@ package repo
 
 import (
 	"context"
+	"time"
 
-	"github.com/google/uuid"
 	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/google/uuid"
 )
 
+// SubscriptionRepository stores subscriptions. Every method returning
+// subscriptions fills in their PriceChanges, PlanChanges, Pauses and
+// Discounts.
 type SubscriptionRepository interface {
 	Create(ctx context.Context, s *domain.Subscription) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// GetForUpdate is GetByID that also locks the row until the end of
+	// the transaction; see TxManager.
+	GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// Update and Delete only apply while the stored version equals the
+	// given one (s.Version for Update), unless it is 0, and fail with
+	// domain.ErrPreconditionFailed otherwise. Update refreshes s with the
+	// stored row.
 	Update(ctx context.Context, s *domain.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
+	// Delete only marks the subscription deleted. Deleted subscriptions
+	// are invisible to every other method except List with
+	// IncludeDeleted or OnlyDeleted, Restore and Purge.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	// Restore undoes Delete; it and GetDeletedForUpdate, which locks the
+	// deleted subscription, fail with domain.ErrConflict when the
+	// subscription is not deleted.
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	GetDeletedForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// Purge removes subscriptions deleted before deletedBefore for good.
+	Purge(ctx context.Context, deletedBefore time.Time) (int64, error)
+	// Exists reports whether the subscription is stored, deleted ones
+	// included.
+	Exists(ctx context.Context, id uuid.UUID) (bool, error)
 	List(ctx context.Context, filter ListFilter) ([]domain.Subscription, error)
-}
+	// Count returns how many subscriptions match filter, ignoring its
+	// sort and paging.
+	Count(ctx context.Context, filter ListFilter) (int, error)
 
+	// SetPriceChange schedules or records a price change; one on the same
+	// day is replaced. DeletePriceChange fails with domain.ErrNotFound when
+	// there is no change on effectiveFrom.
+	SetPriceChange(ctx context.Context, id uuid.UUID, pc domain.PriceChange) error
+	DeletePriceChange(ctx context.Context, id uuid.UUID, effectiveFrom time.Time) error
+	// SetPlanChange and DeletePlanChange do the same for plan changes.
+	SetPlanChange(ctx context.Context, id uuid.UUID, pc domain.PlanChange) error
+	DeletePlanChange(ctx context.Context, id uuid.UUID, effectiveFrom time.Time) error
+	// AddPause stores a pause; EndPause sets the end of the one starting
+	// on start and DeletePause removes it, both failing with
+	// domain.ErrNotFound when there is none. Overlaps are not checked.
+	AddPause(ctx context.Context, id uuid.UUID, p domain.Pause) error
+	EndPause(ctx context.Context, id uuid.UUID, start, end time.Time) error
+	DeletePause(ctx context.Context, id uuid.UUID, start time.Time) error
+	// AddDiscount stores d and sets its ID; DeleteDiscount fails with
+	// domain.ErrNotFound when the subscription has no such discount.
+	AddDiscount(ctx context.Context, id uuid.UUID, d *domain.Discount) error
+	DeleteDiscount(ctx context.Context, id uuid.UUID, discountID int64) error
 
+	// SumCharges sums the prices in effect on every billing date inside the
+	// filter window and the discounts on them, grouped by calendar month
+	// and currency.
+	SumCharges(ctx context.Context, filter ChargeFilter) ([]ChargeSum, error)
+}
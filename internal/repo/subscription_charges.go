@@ -0,0 +1,198 @@
+package repo
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// monthDiff renders the number of calendar months from a to b, ignoring
+// the day of month.
+func monthDiff(a, b string) string {
+	return fmt.Sprintf(
+		"((EXTRACT(YEAR FROM %[2]s)::int - EXTRACT(YEAR FROM %[1]s)::int) * 12"+
+			" + EXTRACT(MONTH FROM %[2]s)::int - EXTRACT(MONTH FROM %[1]s)::int)",
+		a, b,
+	)
+}
+
+// periodIndex renders the index of the last billing date that falls in the
+// same calendar month (or week) as day, or earlier. Billing dates are
+// anchor + n * step, so every n below the index of lo and above the index
+// of hi can be skipped.
+func periodIndex(day string) string {
+	return fmt.Sprintf(`
+		CASE a.billing_unit
+		    WHEN 'week' THEN (%[1]s - a.anchor) / (7 * a.billing_count)
+		    WHEN 'year' THEN %[2]s / (12 * a.billing_count)
+		    ELSE %[2]s / a.billing_count
+		END`,
+		day, monthDiff("a.anchor", day),
+	)
+}
+
+// SumCharges mirrors the billed mode of the service's in-memory engine:
+// billing is split into segments as by domain.Subscription.Segments, the
+// first anchored the day after the trial or on start_date and every other
+// on the day a plan change takes effect. Each segment is charged on
+// anchor + k * its interval (month arithmetic clamps to the month end, as
+// PostgreSQL does), for every such date inside the segment, the window and
+// the subscription lifetime and outside its pauses, the price in effect on
+// that date less the discounts covering it. Billing periods are numbered
+// across segments for the discounts.
+func (r *SubscriptionPostgres) SumCharges(ctx context.Context, f ChargeFilter) ([]ChargeSum, error) {
+	var (
+		conds = []string{"deleted_at IS NULL", "start_date <= $2", "(end_date IS NULL OR end_date >= $1)"}
+		args  = []any{f.From, f.To}
+		argN  = 3
+	)
+
+	if f.UserID != nil {
+		conds = append(conds, fmt.Sprintf("user_id = $%d", argN))
+		args = append(args, *f.UserID)
+		argN++
+	}
+
+	var service []string
+
+	if f.ServiceID != nil {
+		service = append(service, fmt.Sprintf("service_id = $%d", argN))
+		args = append(args, *f.ServiceID)
+		argN++
+	}
+
+	if f.ServiceName != nil {
+		service = append(service, fmt.Sprintf("service_name = $%d", argN))
+		args = append(args, *f.ServiceName)
+	}
+
+	if len(service) > 0 {
+		conds = append(conds, "("+strings.Join(service, " OR ")+")")
+	}
+
+	cols := []string{"date_trunc('month', charged_on)::date", "currency"}
+	if f.GroupByService {
+		cols = append(cols, "service_name")
+	} else {
+		cols = append(cols, "''::text")
+	}
+	if f.GroupByUser {
+		cols = append(cols, "user_id")
+	} else {
+		cols = append(cols, "'00000000-0000-0000-0000-000000000000'::uuid")
+	}
+
+	groupBy := []string{"1", "2"}
+	if f.GroupByService {
+		groupBy = append(groupBy, "3")
+	}
+	if f.GroupByUser {
+		groupBy = append(groupBy, "4")
+	}
+
+	query := `
+		WITH active AS (
+		    SELECT id, user_id, service_name, currency, price,
+		           billing_unit, billing_count,
+		           COALESCE(trial_end_date + 1, start_date) AS anchor,
+		           LEAST(COALESCE(end_date, $2::date), $2::date) AS hi
+		    FROM subscriptions
+		    WHERE ` + strings.Join(conds, " AND ") + `
+		),
+		segments AS (
+		    SELECT DISTINCT ON (id, anchor) *
+		    FROM (
+		        SELECT a.*, '-infinity'::date AS effective_from
+		        FROM active a
+		        UNION ALL
+		        SELECT a.id, a.user_id, a.service_name, a.currency, a.price,
+		               pc.billing_unit, pc.billing_count,
+		               GREATEST(pc.effective_from, a.anchor), a.hi, pc.effective_from
+		        FROM active a
+		        JOIN subscription_plan_changes pc ON pc.subscription_id = a.id
+		    ) s
+		    ORDER BY id, anchor, effective_from DESC
+		),
+		spans AS (
+		    SELECT s.*,
+		           LEAST(s.hi, LEAD(s.anchor) OVER (PARTITION BY s.id ORDER BY s.anchor) - 1) AS seg_end,
+		           CASE s.billing_unit
+		               WHEN 'week' THEN make_interval(days => 7 * s.billing_count)
+		               WHEN 'year' THEN make_interval(years => s.billing_count)
+		               ELSE make_interval(months => s.billing_count)
+		           END AS step
+		    FROM segments s
+		),
+		counted AS (
+		    SELECT a.*,
+		           (SELECT count(*)
+		            FROM generate_series(0, ` + periodIndex("a.seg_end") + `) AS k
+		            WHERE (a.anchor + a.step * k)::date <= a.seg_end
+		           )::int AS span_periods
+		    FROM spans a
+		),
+		periods AS (
+		    SELECT a.*,
+		           COALESCE(SUM(a.span_periods) OVER (
+		               PARTITION BY a.id ORDER BY a.anchor
+		               ROWS BETWEEN UNBOUNDED PRECEDING AND 1 PRECEDING
+		           ), 0) AS n_offset,
+		           GREATEST(a.anchor, $1::date) AS lo,
+		           ` + periodIndex("GREATEST(a.anchor, $1::date)") + ` AS first_k,
+		           ` + periodIndex("a.seg_end") + ` AS last_k
+		    FROM counted a
+		),
+		charges AS (
+		    SELECT p.*, p.n_offset + k AS n, (p.anchor + p.step * k)::date AS charged_on
+		    FROM periods p
+		    CROSS JOIN LATERAL generate_series(p.first_k, p.last_k) AS k
+		    WHERE p.lo <= p.seg_end
+		),
+		billed AS (
+		    SELECT c.*,
+		           COALESCE((
+		               SELECT x.price
+		               FROM (
+		                   SELECT sp.effective_from, 1 AS by_price, sp.price
+		                   FROM subscription_prices sp
+		                   WHERE sp.subscription_id = c.id
+		                   UNION ALL
+		                   SELECT pc.effective_from, 0, pc.price
+		                   FROM subscription_plan_changes pc
+		                   WHERE pc.subscription_id = c.id
+		               ) x
+		               WHERE x.effective_from <= c.charged_on
+		               ORDER BY x.effective_from DESC, x.by_price DESC
+		               LIMIT 1
+		           ), c.price) AS gross
+		    FROM charges c
+		    WHERE c.charged_on BETWEEN c.lo AND c.seg_end
+		      AND NOT ` + pausedOn("c", "c.charged_on") + `
+		),
+		discounted AS (
+		    SELECT b.*, ` + discountOn("b", "b.gross") + ` AS discount
+		    FROM billed b
+		)
+		SELECT ` + strings.Join(cols, ", ") + `, SUM(gross)::float8, SUM(discount)::float8
+		FROM discounted
+		GROUP BY ` + strings.Join(groupBy, ", ") + `
+		ORDER BY 1, 2
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var res []ChargeSum
+	for rows.Next() {
+		var cs ChargeSum
+		if err := rows.Scan(&cs.Month, &cs.Currency, &cs.ServiceName, &cs.UserID, &cs.Gross, &cs.Discount); err != nil {
+			return nil, err
+		}
+		res = append(res, cs)
+	}
+
+	return res, rows.Err()
+}
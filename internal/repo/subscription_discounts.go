@@ -0,0 +1,85 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// discountOn renders the discount on the charge aliased c, whose gross
+// amount is gross; see domain.Subscription.DiscountOn.
+func discountOn(c, gross string) string {
+	return `LEAST(` + gross + `, COALESCE((
+		SELECT SUM(CASE sd.kind
+		           WHEN 'percent' THEN ` + gross + ` * sd.value / 100.0
+		           ELSE sd.value
+		       END)
+		FROM subscription_discounts sd
+		WHERE sd.subscription_id = ` + c + `.id
+		  AND CASE
+		      WHEN sd.periods IS NOT NULL THEN ` + c + `.n < sd.periods
+		      ELSE sd.start_date <= ` + c + `.charged_on
+		           AND (sd.end_date IS NULL OR sd.end_date >= ` + c + `.charged_on)
+		  END
+	), 0))`
+}
+
+// AddDiscount stores d and sets its ID.
+func (r *SubscriptionPostgres) AddDiscount(ctx context.Context, id uuid.UUID, d *domain.Discount) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO subscription_discounts
+		    (subscription_id, kind, value, start_date, end_date, periods)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0))
+		RETURNING id
+	`, id, d.Kind, d.Value, d.StartDate, d.EndDate, d.Periods).Scan(&d.ID)
+
+	return mapError(err)
+}
+
+func (r *SubscriptionPostgres) DeleteDiscount(ctx context.Context, id uuid.UUID, discountID int64) error {
+	return execOne(ctx, conn(ctx, r.db), `
+		DELETE FROM subscription_discounts
+		WHERE subscription_id = $1 AND id = $2
+	`, id, discountID)
+}
+
+// loadDiscounts fills in the Discounts of subs with one query.
+func loadDiscounts(ctx context.Context, db dbtx, subs []*domain.Subscription) error {
+	byID := make(map[uuid.UUID]*domain.Subscription, len(subs))
+	ids := make([]uuid.UUID, 0, len(subs))
+	for _, s := range subs {
+		s.Discounts = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT subscription_id, id, kind, value, start_date, end_date, periods
+		FROM subscription_discounts
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, id
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id      uuid.UUID
+			d       domain.Discount
+			periods sql.NullInt64
+		)
+		if err := rows.Scan(&id, &d.ID, &d.Kind, &d.Value, &d.StartDate, &d.EndDate, &periods); err != nil {
+			return err
+		}
+		d.Periods = int(periods.Int64)
+		s := byID[id]
+		s.Discounts = append(s.Discounts, d)
+	}
+
+	return rows.Err()
+}
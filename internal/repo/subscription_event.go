@@ -0,0 +1,16 @@
+package repo
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type SubscriptionEventRepository interface {
+	// Append records e; call it within the transaction of the change.
+	Append(ctx context.Context, e *domain.SubscriptionEvent) error
+	// List returns the history of a subscription, oldest first.
+	List(ctx context.Context, subscriptionID uuid.UUID) ([]domain.SubscriptionEvent, error)
+}
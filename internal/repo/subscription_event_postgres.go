@@ -0,0 +1,271 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// subscriptionSnapshot is the stored JSON form of a subscription in the
+// history; it must stay readable as the table evolves, so fields are only
+// ever added.
+type subscriptionSnapshot struct {
+	ID           uuid.UUID             `json:"id"`
+	UserID       uuid.UUID             `json:"user_id"`
+	ServiceName  string                `json:"service_name"`
+	ServiceID    *uuid.UUID            `json:"service_id,omitempty"`
+	PlanID       *uuid.UUID            `json:"plan_id,omitempty"`
+	Price        int                   `json:"price"`
+	Currency     string                `json:"currency"`
+	BillingUnit  string                `json:"billing_unit"`
+	BillingCount int                   `json:"billing_count"`
+	StartDate    time.Time             `json:"start_date"`
+	EndDate      *time.Time            `json:"end_date,omitempty"`
+	TrialEndDate *time.Time            `json:"trial_end_date,omitempty"`
+	PriceChanges []priceChangeSnapshot `json:"price_changes,omitempty"`
+	PlanChanges  []planChangeSnapshot  `json:"plan_changes,omitempty"`
+	Pauses       []pauseSnapshot       `json:"pauses,omitempty"`
+	Discounts    []discountSnapshot    `json:"discounts,omitempty"`
+	Version      int                   `json:"version"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	DeletedAt    *time.Time            `json:"deleted_at,omitempty"`
+}
+
+type priceChangeSnapshot struct {
+	EffectiveFrom time.Time `json:"effective_from"`
+	Price         int       `json:"price"`
+}
+
+type planChangeSnapshot struct {
+	EffectiveFrom time.Time  `json:"effective_from"`
+	PlanID        *uuid.UUID `json:"plan_id,omitempty"`
+	PlanName      string     `json:"plan_name"`
+	Price         int        `json:"price"`
+	BillingUnit   string     `json:"billing_unit"`
+	BillingCount  int        `json:"billing_count"`
+}
+
+type pauseSnapshot struct {
+	StartDate time.Time  `json:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+}
+
+type discountSnapshot struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	Value     int        `json:"value"`
+	StartDate *time.Time `json:"start_date,omitempty"`
+	EndDate   *time.Time `json:"end_date,omitempty"`
+	Periods   int        `json:"periods,omitempty"`
+}
+
+func marshalSnapshot(s *domain.Subscription) ([]byte, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var prices []priceChangeSnapshot
+	for _, pc := range s.PriceChanges {
+		prices = append(prices, priceChangeSnapshot(pc))
+	}
+	var plans []planChangeSnapshot
+	for _, pc := range s.PlanChanges {
+		plans = append(plans, planChangeSnapshot{
+			EffectiveFrom: pc.EffectiveFrom,
+			PlanID:        pc.PlanID,
+			PlanName:      pc.PlanName,
+			Price:         pc.Price,
+			BillingUnit:   string(pc.Billing.Unit),
+			BillingCount:  pc.Billing.Count,
+		})
+	}
+	var pauses []pauseSnapshot
+	for _, p := range s.Pauses {
+		pauses = append(pauses, pauseSnapshot(p))
+	}
+	var discounts []discountSnapshot
+	for _, d := range s.Discounts {
+		discounts = append(discounts, discountSnapshot{
+			ID:        d.ID,
+			Kind:      string(d.Kind),
+			Value:     d.Value,
+			StartDate: d.StartDate,
+			EndDate:   d.EndDate,
+			Periods:   d.Periods,
+		})
+	}
+
+	return json.Marshal(subscriptionSnapshot{
+		ID:           s.ID,
+		UserID:       s.UserID,
+		ServiceName:  s.ServiceName,
+		ServiceID:    s.ServiceID,
+		PlanID:       s.PlanID,
+		Price:        s.Price,
+		Currency:     s.Currency,
+		BillingUnit:  string(s.Billing.Unit),
+		BillingCount: s.Billing.Count,
+		StartDate:    s.StartDate,
+		EndDate:      s.EndDate,
+		TrialEndDate: s.TrialEndDate,
+		PriceChanges: prices,
+		PlanChanges:  plans,
+		Pauses:       pauses,
+		Discounts:    discounts,
+		Version:      s.Version,
+		CreatedAt:    s.CreatedAt,
+		UpdatedAt:    s.UpdatedAt,
+		DeletedAt:    s.DeletedAt,
+	})
+}
+
+func unmarshalSnapshot(b []byte) (*domain.Subscription, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	var snap subscriptionSnapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, err
+	}
+
+	var prices []domain.PriceChange
+	for _, pc := range snap.PriceChanges {
+		prices = append(prices, domain.PriceChange(pc))
+	}
+	var plans []domain.PlanChange
+	for _, pc := range snap.PlanChanges {
+		plans = append(plans, domain.PlanChange{
+			EffectiveFrom: pc.EffectiveFrom,
+			PlanID:        pc.PlanID,
+			PlanName:      pc.PlanName,
+			Price:         pc.Price,
+			Billing: domain.BillingInterval{
+				Unit:  domain.BillingUnit(pc.BillingUnit),
+				Count: pc.BillingCount,
+			},
+		})
+	}
+	var pauses []domain.Pause
+	for _, p := range snap.Pauses {
+		pauses = append(pauses, domain.Pause(p))
+	}
+	var discounts []domain.Discount
+	for _, d := range snap.Discounts {
+		discounts = append(discounts, domain.Discount{
+			ID:        d.ID,
+			Kind:      domain.DiscountKind(d.Kind),
+			Value:     d.Value,
+			StartDate: d.StartDate,
+			EndDate:   d.EndDate,
+			Periods:   d.Periods,
+		})
+	}
+
+	return &domain.Subscription{
+		ID:          snap.ID,
+		UserID:      snap.UserID,
+		ServiceName: snap.ServiceName,
+		ServiceID:   snap.ServiceID,
+		PlanID:      snap.PlanID,
+		Price:       snap.Price,
+		Currency:    snap.Currency,
+		Billing: domain.BillingInterval{
+			Unit:  domain.BillingUnit(snap.BillingUnit),
+			Count: snap.BillingCount,
+		},
+		StartDate:    snap.StartDate,
+		EndDate:      snap.EndDate,
+		TrialEndDate: snap.TrialEndDate,
+		PriceChanges: prices,
+		PlanChanges:  plans,
+		Pauses:       pauses,
+		Discounts:    discounts,
+		Version:      snap.Version,
+		CreatedAt:    snap.CreatedAt,
+		UpdatedAt:    snap.UpdatedAt,
+		DeletedAt:    snap.DeletedAt,
+	}, nil
+}
+
+type SubscriptionEventPostgres struct {
+	db *sql.DB
+}
+
+func NewSubscriptionEventPostgres(db *sql.DB) *SubscriptionEventPostgres {
+	return &SubscriptionEventPostgres{db: db}
+}
+
+func (r *SubscriptionEventPostgres) Append(ctx context.Context, e *domain.SubscriptionEvent) error {
+	before, err := marshalSnapshot(e.Before)
+	if err != nil {
+		return err
+	}
+	after, err := marshalSnapshot(e.After)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO subscription_events
+		    (subscription_id, type, before, after, actor, request_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		RETURNING id, occurred_at
+	`
+
+	err = conn(ctx, r.db).QueryRowContext(
+		ctx,
+		query,
+		e.SubscriptionID,
+		e.Type,
+		before,
+		after,
+		e.Actor,
+		e.RequestID,
+	).Scan(&e.ID, &e.OccurredAt)
+
+	return mapError(err)
+}
+
+func (r *SubscriptionEventPostgres) List(ctx context.Context, subscriptionID uuid.UUID) ([]domain.SubscriptionEvent, error) {
+	query := `
+		SELECT id, subscription_id, type, before, after,
+		       COALESCE(actor, ''), COALESCE(request_id, ''), occurred_at
+		FROM subscription_events
+		WHERE subscription_id = $1
+		ORDER BY id
+	`
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, subscriptionID)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	defer rows.Close()
+
+	var res []domain.SubscriptionEvent
+	for rows.Next() {
+		var (
+			e             domain.SubscriptionEvent
+			before, after []byte
+		)
+		if err := rows.Scan(&e.ID, &e.SubscriptionID, &e.Type, &before, &after,
+			&e.Actor, &e.RequestID, &e.OccurredAt); err != nil {
+			return nil, err
+		}
+		if e.Before, err = unmarshalSnapshot(before); err != nil {
+			return nil, err
+		}
+		if e.After, err = unmarshalSnapshot(after); err != nil {
+			return nil, err
+		}
+		res = append(res, e)
+	}
+
+	return res, rows.Err()
+}
@@ -4,14 +4,71 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 )
 
 type ListFilter struct {
-	UserID      *uuid.UUID
+	UserID *uuid.UUID
+	// ServiceID keeps subscriptions linked to that catalog entry. When
+	// ServiceName is set as well, either of them matching is enough.
+	ServiceID   *uuid.UUID
 	ServiceName *string
-	From        *time.Time
-	To          *time.Time
+	// ServiceNameFold compares ServiceName case-insensitively and
+	// ServiceNamePrefix matches it as a prefix; both may be set.
+	ServiceNameFold   bool
+	ServiceNamePrefix bool
+	From              *time.Time
+	To                *time.Time
+
+	PriceMin *int
+	PriceMax *int
+	// ActiveOn keeps subscriptions running on that day.
+	ActiveOn *time.Time
+	// Status is evaluated against the current date.
+	Status domain.Status
+	// TrialEndingWithin keeps trials ending between today and that many
+	// days from now.
+	TrialEndingWithin *int
 
+	// Deleted subscriptions are left out unless IncludeDeleted is set;
+	// OnlyDeleted lists the trash.
+	IncludeDeleted bool
+	OnlyDeleted    bool
+
+	// Sort defaults to DefaultSort.
+	Sort   []SortKey
 	Limit  int
 	Offset int
-}
\ No newline at end of file
+	// After switches to keyset pagination: only rows ordered after the
+	// cursor are returned and Offset is ignored. It must come from the
+	// same Sort.
+	After *Cursor
+}
+
+type ChargeFilter struct {
+	UserID *uuid.UUID
+	// ServiceID and ServiceName match as in ListFilter.
+	ServiceID   *uuid.UUID
+	ServiceName *string
+
+	// From and To are inclusive days.
+	From time.Time
+	To   time.Time
+
+	// GroupByService and GroupByUser additionally split the sums.
+	GroupByService bool
+	GroupByUser    bool
+}
+
+// ChargeSum is the amount charged in one month and currency: Gross at
+// full price, of which Discount was taken off. ServiceName and UserID are
+// only filled when the filter groups by them.
+type ChargeSum struct {
+	Month       time.Time
+	Currency    string
+	ServiceName string
+	UserID      uuid.UUID
+	Gross       float64
+	Discount    float64
+}
@@ -0,0 +1,80 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// pausedOn renders "the subscription aliased s is paused on day".
+func pausedOn(s, day string) string {
+	return `EXISTS (
+		SELECT 1 FROM subscription_pauses sp
+		WHERE sp.subscription_id = ` + s + `.id
+		  AND sp.start_date <= ` + day + `
+		  AND (sp.end_date IS NULL OR sp.end_date >= ` + day + `)
+	)`
+}
+
+func (r *SubscriptionPostgres) AddPause(ctx context.Context, id uuid.UUID, p domain.Pause) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO subscription_pauses (subscription_id, start_date, end_date)
+		VALUES ($1, $2, $3)
+	`, id, p.StartDate, p.EndDate)
+
+	return mapError(err)
+}
+
+func (r *SubscriptionPostgres) EndPause(ctx context.Context, id uuid.UUID, start, end time.Time) error {
+	return execOne(ctx, conn(ctx, r.db), `
+		UPDATE subscription_pauses
+		SET end_date = $3
+		WHERE subscription_id = $1 AND start_date = $2
+	`, id, start, end)
+}
+
+func (r *SubscriptionPostgres) DeletePause(ctx context.Context, id uuid.UUID, start time.Time) error {
+	return execOne(ctx, conn(ctx, r.db), `
+		DELETE FROM subscription_pauses
+		WHERE subscription_id = $1 AND start_date = $2
+	`, id, start)
+}
+
+// loadPauses fills in the Pauses of subs with one query.
+func loadPauses(ctx context.Context, db dbtx, subs []*domain.Subscription) error {
+	byID := make(map[uuid.UUID]*domain.Subscription, len(subs))
+	ids := make([]uuid.UUID, 0, len(subs))
+	for _, s := range subs {
+		s.Pauses = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT subscription_id, start_date, end_date
+		FROM subscription_pauses
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, start_date
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			p  domain.Pause
+		)
+		if err := rows.Scan(&id, &p.StartDate, &p.EndDate); err != nil {
+			return err
+		}
+		s := byID[id]
+		s.Pauses = append(s.Pauses, p)
+	}
+
+	return rows.Err()
+}
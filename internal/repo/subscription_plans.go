@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// SetPlanChange records pc, replacing a change on the same day.
+func (r *SubscriptionPostgres) SetPlanChange(ctx context.Context, id uuid.UUID, pc domain.PlanChange) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO subscription_plan_changes
+		    (subscription_id, effective_from, plan_id, plan_name, price, billing_unit, billing_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE
+		SET plan_id = EXCLUDED.plan_id,
+		    plan_name = EXCLUDED.plan_name,
+		    price = EXCLUDED.price,
+		    billing_unit = EXCLUDED.billing_unit,
+		    billing_count = EXCLUDED.billing_count
+	`, id, pc.EffectiveFrom, pc.PlanID, pc.PlanName, pc.Price, pc.Billing.Unit, pc.Billing.Count)
+
+	return mapError(err)
+}
+
+func (r *SubscriptionPostgres) DeletePlanChange(ctx context.Context, id uuid.UUID, effectiveFrom time.Time) error {
+	return execOne(ctx, conn(ctx, r.db), `
+		DELETE FROM subscription_plan_changes
+		WHERE subscription_id = $1 AND effective_from = $2
+	`, id, effectiveFrom)
+}
+
+// loadPlanChanges fills in the PlanChanges of subs with one query.
+func loadPlanChanges(ctx context.Context, db dbtx, subs []*domain.Subscription) error {
+	byID := make(map[uuid.UUID]*domain.Subscription, len(subs))
+	ids := make([]uuid.UUID, 0, len(subs))
+	for _, s := range subs {
+		s.PlanChanges = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT subscription_id, effective_from, plan_id, plan_name, price, billing_unit, billing_count
+		FROM subscription_plan_changes
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, effective_from
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			pc domain.PlanChange
+		)
+		err := rows.Scan(&id, &pc.EffectiveFrom, &pc.PlanID, &pc.PlanName, &pc.Price, &pc.Billing.Unit, &pc.Billing.Count)
+		if err != nil {
+			return err
+		}
+		s := byID[id]
+		s.PlanChanges = append(s.PlanChanges, pc)
+	}
+
+	return rows.Err()
+}
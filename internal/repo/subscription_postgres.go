@@ -3,13 +3,63 @@ package repo
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 	"github.com/google/uuid"
 )
 
+const subscriptionColumns = `
+	id, user_id, service_name, service_id, plan_id, price, currency,
+	billing_unit, billing_count,
+	start_date, end_date, trial_end_date, version, created_at, updated_at, deleted_at
+`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanSubscription(row rowScanner, s *domain.Subscription) error {
+	return row.Scan(
+		&s.ID,
+		&s.UserID,
+		&s.ServiceName,
+		&s.ServiceID,
+		&s.PlanID,
+		&s.Price,
+		&s.Currency,
+		&s.Billing.Unit,
+		&s.Billing.Count,
+		&s.StartDate,
+		&s.EndDate,
+		&s.TrialEndDate,
+		&s.Version,
+		&s.CreatedAt,
+		&s.UpdatedAt,
+		&s.DeletedAt,
+	)
+}
+
+// loadDetails fills in what subs keep in child tables.
+func loadDetails(ctx context.Context, db dbtx, subs ...*domain.Subscription) error {
+	if len(subs) == 0 {
+		return nil
+	}
+	if err := loadPriceChanges(ctx, db, subs); err != nil {
+		return err
+	}
+	if err := loadPlanChanges(ctx, db, subs); err != nil {
+		return err
+	}
+	if err := loadPauses(ctx, db, subs); err != nil {
+		return err
+	}
+	return loadDiscounts(ctx, db, subs)
+}
+
 type SubscriptionPostgres struct {
 	db *sql.DB
 }
@@ -21,95 +71,150 @@ func NewSubscriptionPostgres(db *sql.DB) *SubscriptionPostgres {
 func (r *SubscriptionPostgres) Create(ctx context.Context, s *domain.Subscription) error {
 	query := `
 		INSERT INTO subscriptions
-		    (user_id, service_name, price, start_date, end_date)
-		VALUES ($1, $2, $3, $4, $5)
-		RETURNING id, created_at, updated_at
+		    (user_id, service_name, service_id, plan_id, price, currency,
+		     billing_unit, billing_count, start_date, end_date, trial_end_date)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id, version, created_at, updated_at
 	`
 
-	return r.db.QueryRowContext(
+	err := conn(ctx, r.db).QueryRowContext(
 		ctx,
 		query,
 		s.UserID,
 		s.ServiceName,
+		s.ServiceID,
+		s.PlanID,
 		s.Price,
+		s.Currency,
+		s.Billing.Unit,
+		s.Billing.Count,
 		s.StartDate,
 		s.EndDate,
-	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		s.TrialEndDate,
+	).Scan(&s.ID, &s.Version, &s.CreatedAt, &s.UpdatedAt)
+
+	return mapError(err)
 }
 
 func (r *SubscriptionPostgres) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
-	query := `
-		SELECT id, user_id, service_name, price,
-		       start_date, end_date, created_at, updated_at
+	query := `SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE id = $1
+		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	var s domain.Subscription
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&s.ID,
-		&s.UserID,
-		&s.ServiceName,
-		&s.Price,
-		&s.StartDate,
-		&s.EndDate,
-		&s.CreatedAt,
-		&s.UpdatedAt,
-	)
+	db := conn(ctx, r.db)
 
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, err
+	var s domain.Subscription
+	if err := scanSubscription(db.QueryRowContext(ctx, query, id), &s); err != nil {
+		return nil, mapError(err)
 	}
 
-	return &s, nil
+	return &s, loadDetails(ctx, db, &s)
 }
 
-func (r *SubscriptionPostgres) Update(ctx context.Context, s *domain.Subscription) error {
+func updateSubscription(ctx context.Context, db dbtx, s *domain.Subscription) error {
 	query := `
 		UPDATE subscriptions
 		SET service_name = $1,
-		    price = $2,
-		    start_date = $3,
-		    end_date = $4,
+		    service_id = $2,
+		    plan_id = $3,
+		    price = $4,
+		    currency = $5,
+		    billing_unit = $6,
+		    billing_count = $7,
+		    start_date = $8,
+		    end_date = $9,
+		    trial_end_date = $10,
+		    version = version + 1,
 		    updated_at = now()
-		WHERE id = $5
-	`
+		WHERE id = $11 AND deleted_at IS NULL AND ($12 = 0 OR version = $12)
+		RETURNING ` + subscriptionColumns
 
-	res, err := r.db.ExecContext(
+	row := db.QueryRowContext(
 		ctx,
 		query,
 		s.ServiceName,
+		s.ServiceID,
+		s.PlanID,
 		s.Price,
+		s.Currency,
+		s.Billing.Unit,
+		s.Billing.Count,
 		s.StartDate,
 		s.EndDate,
+		s.TrialEndDate,
 		s.ID,
+		s.Version,
 	)
+
+	err := scanSubscription(row, s)
+	if errors.Is(err, sql.ErrNoRows) {
+		return missingOrStale(ctx, db, s.ID)
+	}
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
-	aff, err := res.RowsAffected()
+	return loadDetails(ctx, db, s)
+}
+
+func (r *SubscriptionPostgres) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
+	var exists bool
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1)
+	`, id).Scan(&exists)
+
+	return exists, mapError(err)
+}
+
+// missingOrStale tells why a versioned write matched no row; deleted
+// subscriptions count as missing.
+func missingOrStale(ctx context.Context, db dbtx, id uuid.UUID) error {
+	var exists bool
+	err := db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM subscriptions WHERE id = $1 AND deleted_at IS NULL)
+	`, id).Scan(&exists)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
-	if aff == 0 {
-		return sql.ErrNoRows
+	if exists {
+		return domain.ErrPreconditionFailed
 	}
+	return domain.ErrNotFound
+}
 
-	return nil
+func (r *SubscriptionPostgres) Update(ctx context.Context, s *domain.Subscription) error {
+	return updateSubscription(ctx, conn(ctx, r.db), s)
 }
 
-func (r *SubscriptionPostgres) Delete(ctx context.Context, id uuid.UUID) error {
-	res, err := r.db.ExecContext(
-		ctx,
-		`DELETE FROM subscriptions WHERE id = $1`,
-		id,
-	)
+func (r *SubscriptionPostgres) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1 AND deleted_at IS NULL
+		FOR UPDATE
+	`
+
+	db := conn(ctx, r.db)
+
+	var s domain.Subscription
+	if err := scanSubscription(db.QueryRowContext(ctx, query, id), &s); err != nil {
+		return nil, mapError(err)
+	}
+
+	return &s, loadDetails(ctx, db, &s)
+}
+
+// Delete moves the subscription to the trash; see Purge.
+func (r *SubscriptionPostgres) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE subscriptions
+		SET deleted_at = now(),
+		    version = version + 1,
+		    updated_at = now()
+		WHERE id = $1 AND deleted_at IS NULL AND ($2 = 0 OR version = $2)
+	`, id, version)
 	if err != nil {
-		return err
+		return mapError(err)
 	}
 
 	aff, err := res.RowsAffected()
@@ -117,31 +222,122 @@ func (r *SubscriptionPostgres) Delete(ctx context.Context, id uuid.UUID) error {
 		return err
 	}
 	if aff == 0 {
-		return sql.ErrNoRows
+		return missingOrStale(ctx, conn(ctx, r.db), id)
 	}
 
 	return nil
 }
 
-func (r *SubscriptionPostgres) List(ctx context.Context, f ListFilter) ([]domain.Subscription, error) {
+func (r *SubscriptionPostgres) GetDeletedForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	query := `SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE id = $1
+		FOR UPDATE
+	`
+
+	db := conn(ctx, r.db)
+
+	var s domain.Subscription
+	if err := scanSubscription(db.QueryRowContext(ctx, query, id), &s); err != nil {
+		return nil, mapError(err)
+	}
+	if s.DeletedAt == nil {
+		return nil, fmt.Errorf("%w: subscription is not deleted", domain.ErrConflict)
+	}
+
+	return &s, loadDetails(ctx, db, &s)
+}
+
+func (r *SubscriptionPostgres) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	query := `
+		UPDATE subscriptions
+		SET deleted_at = NULL,
+		    version = version + 1,
+		    updated_at = now()
+		WHERE id = $1 AND deleted_at IS NOT NULL
+		RETURNING ` + subscriptionColumns
+
+	db := conn(ctx, r.db)
+
+	var s domain.Subscription
+	err := scanSubscription(db.QueryRowContext(ctx, query, id), &s)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Either unknown or not deleted, which GetByID tells apart.
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: subscription is not deleted", domain.ErrConflict)
+	}
+	if err != nil {
+		return nil, mapError(err)
+	}
+
+	return &s, loadDetails(ctx, db, &s)
+}
+
+func (r *SubscriptionPostgres) Purge(ctx context.Context, deletedBefore time.Time) (int64, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx,
+		`DELETE FROM subscriptions WHERE deleted_at < $1`,
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, mapError(err)
+	}
+	return res.RowsAffected()
+}
+
+// listConditions renders the WHERE conditions of f, numbering parameters
+// from argN, and returns the next free number.
+func listConditions(f ListFilter, argN int) ([]string, []any, int) {
 	var (
 		conds []string
 		args  []any
-		argN  = 1
 	)
 
+	switch {
+	case f.OnlyDeleted:
+		conds = append(conds, "deleted_at IS NOT NULL")
+	case !f.IncludeDeleted:
+		conds = append(conds, "deleted_at IS NULL")
+	}
+
 	if f.UserID != nil {
 		conds = append(conds, fmt.Sprintf("user_id = $%d", argN))
 		args = append(args, *f.UserID)
 		argN++
 	}
 
+	var service []string
+
+	if f.ServiceID != nil {
+		service = append(service, fmt.Sprintf("service_id = $%d", argN))
+		args = append(args, *f.ServiceID)
+		argN++
+	}
+
 	if f.ServiceName != nil {
-		conds = append(conds, fmt.Sprintf("service_name = $%d", argN))
-		args = append(args, *f.ServiceName)
+		name := *f.ServiceName
+		switch {
+		case f.ServiceNamePrefix:
+			op := "LIKE"
+			if f.ServiceNameFold {
+				op = "ILIKE"
+			}
+			service = append(service, fmt.Sprintf(`service_name %s $%d ESCAPE '\'`, op, argN))
+			name = likeEscaper.Replace(name) + "%"
+		case f.ServiceNameFold:
+			service = append(service, fmt.Sprintf("lower(service_name) = lower($%d)", argN))
+		default:
+			service = append(service, fmt.Sprintf("service_name = $%d", argN))
+		}
+		args = append(args, name)
 		argN++
 	}
 
+	if len(service) > 0 {
+		conds = append(conds, "("+strings.Join(service, " OR ")+")")
+	}
+
 	if f.From != nil {
 		conds = append(conds, fmt.Sprintf("start_date <= $%d", argN))
 		args = append(args, *f.From)
@@ -154,9 +350,91 @@ func (r *SubscriptionPostgres) List(ctx context.Context, f ListFilter) ([]domain
 		argN++
 	}
 
-	query := `
-		SELECT id, user_id, service_name, price,
-		       start_date, end_date, created_at, updated_at
+	if f.PriceMin != nil {
+		conds = append(conds, fmt.Sprintf("price >= $%d", argN))
+		args = append(args, *f.PriceMin)
+		argN++
+	}
+
+	if f.PriceMax != nil {
+		conds = append(conds, fmt.Sprintf("price <= $%d", argN))
+		args = append(args, *f.PriceMax)
+		argN++
+	}
+
+	if f.TrialEndingWithin != nil {
+		conds = append(conds, fmt.Sprintf("trial_end_date BETWEEN CURRENT_DATE AND CURRENT_DATE + $%d::int", argN))
+		args = append(args, *f.TrialEndingWithin)
+		argN++
+	}
+
+	if f.ActiveOn != nil {
+		day := fmt.Sprintf("$%d::date", argN)
+		conds = append(conds,
+			"start_date <= "+day+" AND (end_date IS NULL OR end_date >= "+day+")",
+			"NOT "+pausedOn("subscriptions", day),
+		)
+		args = append(args, *f.ActiveOn)
+		argN++
+	}
+
+	switch f.Status {
+	case domain.StatusActive:
+		conds = append(conds,
+			"start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)",
+			"NOT "+pausedOn("subscriptions", "CURRENT_DATE"),
+		)
+	case domain.StatusPaused:
+		conds = append(conds,
+			"start_date <= CURRENT_DATE AND (end_date IS NULL OR end_date >= CURRENT_DATE)",
+			pausedOn("subscriptions", "CURRENT_DATE"),
+		)
+	case domain.StatusEnded:
+		conds = append(conds, "end_date < CURRENT_DATE")
+	case domain.StatusUpcoming:
+		conds = append(conds, "start_date > CURRENT_DATE")
+	}
+
+	return conds, args, argN
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+func (r *SubscriptionPostgres) Count(ctx context.Context, f ListFilter) (int, error) {
+	conds, args, _ := listConditions(f, 1)
+
+	query := `SELECT count(*) FROM subscriptions`
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	var n int
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, mapError(err)
+	}
+	return n, nil
+}
+
+func (r *SubscriptionPostgres) List(ctx context.Context, f ListFilter) ([]domain.Subscription, error) {
+	keys := sortOrDefault(f.Sort)
+	terms, err := orderTerms(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	conds, args, argN := listConditions(f, 1)
+
+	if f.After != nil {
+		if f.After.Sort != sortSignature(keys) || len(f.After.Values) != len(keys) {
+			return nil, ErrInvalidCursor
+		}
+		cond, values := keysetCondition(terms, f.After, argN)
+		conds = append(conds, cond)
+		args = append(args, values...)
+		argN += len(values)
+	}
+
+	query := `SELECT ` + subscriptionColumns + `
 		FROM subscriptions
 	`
 
@@ -164,41 +442,43 @@ func (r *SubscriptionPostgres) List(ctx context.Context, f ListFilter) ([]domain
 		query += " WHERE " + strings.Join(conds, " AND ")
 	}
 
-	query += " ORDER BY created_at DESC"
+	query += " ORDER BY " + orderByClause(terms)
 
 	if f.Limit > 0 {
 		query += fmt.Sprintf(" LIMIT $%d", argN)
 		args = append(args, f.Limit)
 		argN++
 	}
-	if f.Offset > 0 {
+	if f.Offset > 0 && f.After == nil {
 		query += fmt.Sprintf(" OFFSET $%d", argN)
 		args = append(args, f.Offset)
 	}
 
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	db := conn(ctx, r.db)
+
+	rows, err := db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, err
+		return nil, mapError(err)
 	}
 	defer rows.Close()
 
 	var res []domain.Subscription
 	for rows.Next() {
 		var s domain.Subscription
-		if err := rows.Scan(
-			&s.ID,
-			&s.UserID,
-			&s.ServiceName,
-			&s.Price,
-			&s.StartDate,
-			&s.EndDate,
-			&s.CreatedAt,
-			&s.UpdatedAt,
-		); err != nil {
+		if err := scanSubscription(rows, &s); err != nil {
 			return nil, err
 		}
 		res = append(res, s)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	subs := make([]*domain.Subscription, len(res))
+	for i := range res {
+		subs[i] = &res[i]
+	}
 
-	return res, rows.Err()
+	return res, loadDetails(ctx, db, subs...)
 }
@@ -0,0 +1,84 @@
+package repo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// SetPriceChange records pc, replacing a change on the same day.
+func (r *SubscriptionPostgres) SetPriceChange(ctx context.Context, id uuid.UUID, pc domain.PriceChange) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO subscription_prices (subscription_id, effective_from, price)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subscription_id, effective_from) DO UPDATE
+		SET price = EXCLUDED.price
+	`, id, pc.EffectiveFrom, pc.Price)
+
+	return mapError(err)
+}
+
+func (r *SubscriptionPostgres) DeletePriceChange(ctx context.Context, id uuid.UUID, effectiveFrom time.Time) error {
+	return execOne(ctx, conn(ctx, r.db), `
+		DELETE FROM subscription_prices
+		WHERE subscription_id = $1 AND effective_from = $2
+	`, id, effectiveFrom)
+}
+
+// execOne runs a statement that must affect a row, failing with
+// domain.ErrNotFound otherwise.
+func execOne(ctx context.Context, db dbtx, query string, args ...any) error {
+	res, err := db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return mapError(err)
+	}
+
+	aff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if aff == 0 {
+		return domain.ErrNotFound
+	}
+
+	return nil
+}
+
+// loadPriceChanges fills in the PriceChanges of subs with one query.
+func loadPriceChanges(ctx context.Context, db dbtx, subs []*domain.Subscription) error {
+	byID := make(map[uuid.UUID]*domain.Subscription, len(subs))
+	ids := make([]uuid.UUID, 0, len(subs))
+	for _, s := range subs {
+		s.PriceChanges = nil
+		byID[s.ID] = s
+		ids = append(ids, s.ID)
+	}
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT subscription_id, effective_from, price
+		FROM subscription_prices
+		WHERE subscription_id = ANY($1)
+		ORDER BY subscription_id, effective_from
+	`, ids)
+	if err != nil {
+		return mapError(err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id uuid.UUID
+			pc domain.PriceChange
+		)
+		if err := rows.Scan(&id, &pc.EffectiveFrom, &pc.Price); err != nil {
+			return err
+		}
+		s := byID[id]
+		s.PriceChanges = append(s.PriceChanges, pc)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,167 @@
+package repo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type SortKey struct {
+	Field string
+	Desc  bool
+}
+
+var DefaultSort = []SortKey{{Field: "created_at", Desc: true}}
+
+type sortColumn struct {
+	// expr is what rows are ordered on; never NULL.
+	expr string
+	// typ casts the cursor value back for comparison with expr.
+	typ   string
+	value func(s *domain.Subscription) string
+}
+
+// sortColumns is the whitelist of sortable fields.
+var sortColumns = map[string]sortColumn{
+	"price": {"price", "integer", func(s *domain.Subscription) string {
+		return strconv.Itoa(s.Price)
+	}},
+	"start_date": {"start_date", "date", func(s *domain.Subscription) string {
+		return s.StartDate.Format(time.DateOnly)
+	}},
+	// Open-ended subscriptions sort as ending last.
+	"end_date": {"COALESCE(end_date, 'infinity'::date)", "date", func(s *domain.Subscription) string {
+		if s.EndDate == nil {
+			return "infinity"
+		}
+		return s.EndDate.Format(time.DateOnly)
+	}},
+	// Subscriptions without a trial sort last.
+	"trial_end_date": {"COALESCE(trial_end_date, 'infinity'::date)", "date", func(s *domain.Subscription) string {
+		if s.TrialEndDate == nil {
+			return "infinity"
+		}
+		return s.TrialEndDate.Format(time.DateOnly)
+	}},
+	"service_name": {"service_name", "text", func(s *domain.Subscription) string {
+		return s.ServiceName
+	}},
+	"created_at": {"created_at", "timestamptz", func(s *domain.Subscription) string {
+		return s.CreatedAt.Format(time.RFC3339Nano)
+	}},
+}
+
+// validSortValue reports whether v is the text form of a value of type
+// typ that PostgreSQL accepts.
+func validSortValue(typ, v string) bool {
+	switch typ {
+	case "integer":
+		_, err := strconv.ParseInt(v, 10, 32)
+		return err == nil
+	case "date":
+		if v == "infinity" {
+			return true
+		}
+		t, err := time.Parse(time.DateOnly, v)
+		return err == nil && t.Year() >= 1
+	case "timestamptz":
+		t, err := time.Parse(time.RFC3339Nano, v)
+		return err == nil && t.Year() >= 1
+	default:
+		return utf8.ValidString(v) && !strings.ContainsRune(v, 0)
+	}
+}
+
+func IsSortField(field string) bool {
+	_, ok := sortColumns[field]
+	return ok
+}
+
+func sortOrDefault(keys []SortKey) []SortKey {
+	if len(keys) == 0 {
+		return DefaultSort
+	}
+	return keys
+}
+
+func sortSignature(keys []SortKey) string {
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		dir := "asc"
+		if k.Desc {
+			dir = "desc"
+		}
+		parts = append(parts, k.Field+":"+dir)
+	}
+	return strings.Join(parts, ",")
+}
+
+// orderTerm is one ORDER BY term of a keyset: the expression, the cast of
+// its cursor parameter and its direction.
+type orderTerm struct {
+	expr, typ string
+	desc      bool
+}
+
+// orderTerms resolves keys against the whitelist and appends id, in the
+// direction of the last key, so that the order is total.
+func orderTerms(keys []SortKey) ([]orderTerm, error) {
+	terms := make([]orderTerm, 0, len(keys)+1)
+	for _, k := range keys {
+		col, ok := sortColumns[k.Field]
+		if !ok {
+			return nil, fmt.Errorf("unknown sort field %q", k.Field)
+		}
+		terms = append(terms, orderTerm{expr: col.expr, typ: col.typ, desc: k.Desc})
+	}
+	return append(terms, orderTerm{expr: "id", typ: "uuid", desc: keys[len(keys)-1].Desc}), nil
+}
+
+func orderByClause(terms []orderTerm) string {
+	parts := make([]string, 0, len(terms))
+	for _, t := range terms {
+		if t.desc {
+			parts = append(parts, t.expr+" DESC")
+		} else {
+			parts = append(parts, t.expr+" ASC")
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// keysetCondition renders "row comes after the cursor" for terms of mixed
+// directions, which a single row comparison cannot express:
+//
+//	k1 > v1 OR (k1 = v1 AND k2 < v2) OR (k1 = v1 AND k2 = v2 AND id > vid)
+func keysetCondition(terms []orderTerm, c *Cursor, argN int) (string, []any) {
+	values := make([]any, 0, len(terms))
+	for _, v := range c.Values {
+		values = append(values, v)
+	}
+	values = append(values, c.ID)
+
+	placeholders := make([]string, len(terms))
+	for i, t := range terms {
+		placeholders[i] = fmt.Sprintf("$%d::%s", argN+i, t.typ)
+	}
+
+	ors := make([]string, 0, len(terms))
+	for i, t := range terms {
+		ands := make([]string, 0, i+1)
+		for j := 0; j < i; j++ {
+			ands = append(ands, terms[j].expr+" = "+placeholders[j])
+		}
+		op := ">"
+		if t.desc {
+			op = "<"
+		}
+		ands = append(ands, t.expr+" "+op+" "+placeholders[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+
+	return "(" + strings.Join(ors, " OR ") + ")", values
+}
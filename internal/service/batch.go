@@ -0,0 +1,176 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+const MaxBatchSize = 100
+
+// ErrBatchAborted is the outcome of the operations of an atomic batch that
+// were not applied because another one failed.
+var ErrBatchAborted = errors.New("not applied: another operation of the batch failed")
+
+type BatchMode string
+
+const (
+	BatchAtomic     BatchMode = "atomic"
+	BatchBestEffort BatchMode = "best_effort"
+)
+
+func (m BatchMode) Valid() bool {
+	return m == BatchAtomic || m == BatchBestEffort
+}
+
+type BatchOp string
+
+const (
+	BatchCreate BatchOp = "create"
+	BatchUpdate BatchOp = "update"
+	BatchDelete BatchOp = "delete"
+)
+
+func (o BatchOp) Valid() bool {
+	switch o {
+	case BatchCreate, BatchUpdate, BatchDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+// BatchOperation is one write of a batch. Update and delete address
+// Subscription.ID and honour a non-zero Subscription.Version like their
+// single counterparts; delete uses no other field.
+type BatchOperation struct {
+	Op           BatchOp
+	Subscription domain.Subscription
+}
+
+// BatchResult is the outcome of the operation at the same index.
+// Subscription is the stored state after create and update.
+type BatchResult struct {
+	Subscription *domain.Subscription
+	Err          error
+}
+
+func (s *subscriptionService) Batch(ctx context.Context, mode BatchMode, ops []BatchOperation) ([]BatchResult, error) {
+	if len(ops) == 0 || len(ops) > MaxBatchSize {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field:   "operations",
+			Code:    "invalid_length",
+			Message: fmt.Sprintf("must hold between 1 and %d operations", MaxBatchSize),
+		})
+	}
+
+	results := make([]BatchResult, len(ops))
+
+	failed := false
+	for i := range ops {
+		if err := s.validateOperation(ctx, &ops[i]); err != nil {
+			results[i].Err = err
+			failed = true
+		}
+	}
+
+	if mode == BatchBestEffort {
+		for i := range ops {
+			if results[i].Err == nil {
+				results[i] = s.applyOperation(ctx, &ops[i])
+			}
+		}
+		return results, nil
+	}
+
+	if !failed {
+		err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+			for i := range ops {
+				results[i] = s.applyOperation(ctx, &ops[i])
+				if results[i].Err != nil {
+					return results[i].Err
+				}
+			}
+			return nil
+		})
+		if err == nil {
+			return results, nil
+		}
+		// The batch can also fail without any operation failing, e.g. on
+		// commit; that is no outcome of the operations but an error.
+		if !anyFailed(results) {
+			return nil, err
+		}
+	}
+
+	// Nothing was committed: every operation that did not fail itself
+	// reports the abort instead of its rolled back result.
+	for i := range results {
+		if results[i].Err == nil {
+			results[i] = BatchResult{Err: ErrBatchAborted}
+		}
+	}
+	return results, nil
+}
+
+func anyFailed(results []BatchResult) bool {
+	for _, r := range results {
+		if r.Err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *subscriptionService) validateOperation(ctx context.Context, op *BatchOperation) error {
+	sub := &op.Subscription
+
+	switch op.Op {
+	case BatchCreate:
+		if err := s.resolveService(ctx, sub); err != nil {
+			return err
+		}
+		return validateNew(sub)
+	case BatchUpdate:
+		if err := s.resolveService(ctx, sub); err != nil {
+			return err
+		}
+		verr := validateSubscription(sub)
+		if sub.ID == uuid.Nil {
+			verr.Add("id", "required", "is required")
+		}
+		return verr.Err()
+	case BatchDelete:
+		if sub.ID == uuid.Nil {
+			return domain.NewValidationError(domain.FieldError{Field: "id", Code: "required", Message: "is required"})
+		}
+		return nil
+	default:
+		return domain.NewValidationError(domain.FieldError{
+			Field: "op", Code: "invalid_operation", Message: "must be one of create, update, delete",
+		})
+	}
+}
+
+func (s *subscriptionService) applyOperation(ctx context.Context, op *BatchOperation) BatchResult {
+	sub := op.Subscription
+
+	var err error
+	switch op.Op {
+	case BatchCreate:
+		err = s.create(ctx, &sub)
+	case BatchUpdate:
+		err = s.update(ctx, &sub)
+	case BatchDelete:
+		return BatchResult{Err: s.delete(ctx, sub.ID, sub.Version)}
+	}
+	if err != nil {
+		return BatchResult{Err: err}
+	}
+
+	return BatchResult{Subscription: &sub}
+}
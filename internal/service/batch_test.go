@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestBatch(t *testing.T) {
+	existing := domain.Subscription{
+		ID: uuid.New(), UserID: uuid.New(), ServiceName: "Netflix",
+		Price: 500, Currency: "RUB", Billing: domain.MonthlyBilling,
+		StartDate: date(2025, time.January, 1),
+	}
+	newSub := func() domain.Subscription {
+		return domain.Subscription{UserID: uuid.New(), ServiceName: "Spotify", Price: 300, StartDate: date(2025, time.February, 1)}
+	}
+	valid := func() []BatchOperation {
+		return []BatchOperation{
+			{Op: BatchCreate, Subscription: newSub()},
+			{Op: BatchDelete, Subscription: domain.Subscription{ID: existing.ID}},
+		}
+	}
+	invalid := BatchOperation{Op: BatchCreate, Subscription: domain.Subscription{ServiceName: "Spotify"}}
+	missing := BatchOperation{Op: BatchDelete, Subscription: domain.Subscription{ID: uuid.New()}}
+	commitErr := errors.New("commit failed")
+
+	var verr *domain.ValidationError
+
+	tests := []struct {
+		name      string
+		mode      BatchMode
+		ops       []BatchOperation
+		commitErr error
+		wantErr   error
+		// want checks the outcome of every operation.
+		want []func(BatchResult) bool
+	}{
+		{
+			name: "atomic applies all",
+			mode: BatchAtomic,
+			ops:  valid(),
+			want: []func(BatchResult) bool{applied, succeeded},
+		},
+		{
+			name: "atomic aborts on an invalid operation",
+			mode: BatchAtomic,
+			ops:  append(valid(), invalid),
+			want: []func(BatchResult) bool{aborted, aborted, func(r BatchResult) bool { return errors.As(r.Err, &verr) }},
+		},
+		{
+			name: "atomic aborts on a failing operation",
+			mode: BatchAtomic,
+			ops:  append(valid(), missing),
+			want: []func(BatchResult) bool{aborted, aborted, failedWith(domain.ErrNotFound)},
+		},
+		{
+			name:      "atomic commit failure is an error",
+			mode:      BatchAtomic,
+			ops:       valid(),
+			commitErr: commitErr,
+			wantErr:   commitErr,
+		},
+		{
+			name: "best effort applies the others",
+			mode: BatchBestEffort,
+			ops:  append(append(valid(), invalid), missing),
+			want: []func(BatchResult) bool{
+				applied, succeeded,
+				func(r BatchResult) bool { return errors.As(r.Err, &verr) },
+				failedWith(domain.ErrNotFound),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, _ := newTestService(newMemSubscriptions(existing), nil, &fakeTx{commitErr: tt.commitErr})
+
+			results, err := svc.Batch(context.Background(), tt.mode, tt.ops)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if results != nil {
+					t.Errorf("got results %+v with the error", results)
+				}
+				return
+			}
+			if len(results) != len(tt.want) {
+				t.Fatalf("got %d results, want %d", len(results), len(tt.want))
+			}
+			for i, ok := range tt.want {
+				if !ok(results[i]) {
+					t.Errorf("operation %d: unexpected outcome %+v", i, results[i])
+				}
+			}
+		})
+	}
+}
+
+func applied(r BatchResult) bool   { return r.Err == nil && r.Subscription != nil }
+func succeeded(r BatchResult) bool { return r.Err == nil }
+func aborted(r BatchResult) bool   { return errors.Is(r.Err, ErrBatchAborted) }
+
+func failedWith(err error) func(BatchResult) bool {
+	return func(r BatchResult) bool { return errors.Is(r.Err, err) }
+}
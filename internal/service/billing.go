@@ -0,0 +1,231 @@
+package service
+
+import (
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type TotalMode string
+
+const (
+	// TotalModeBilled charges the price on every billing date in the window.
+	TotalModeBilled TotalMode = "billed"
+	// TotalModeMonthlyEquivalent spreads the price evenly over the billing
+	// interval and charges that share for every active month in the window.
+	TotalModeMonthlyEquivalent TotalMode = "monthly_equivalent"
+	// TotalModeProrated charges every billing period by the share of its
+	// days that fall inside both the window and the subscription.
+	TotalModeProrated TotalMode = "prorated"
+)
+
+func (m TotalMode) Valid() bool {
+	switch m {
+	case TotalModeBilled, TotalModeMonthlyEquivalent, TotalModeProrated:
+		return true
+	default:
+		return false
+	}
+}
+
+// window is an inclusive range of calendar days.
+type window struct {
+	from time.Time
+	to   time.Time
+}
+
+// chargeFunc receives an amount attributed to a calendar month: gross at
+// full price, of which discount is taken off.
+type chargeFunc func(month time.Time, gross, discount float64)
+
+// eachCharge reports what sub costs inside w under the given mode. Billing
+// starts at sub.BillingAnchor, so a trial costs nothing, and restarts at
+// every plan change with the interval of the plan; see Segments. Pauses do not
+// move billing dates: billed mode skips the dates inside a pause,
+// prorated mode the paused days and monthly equivalent mode the months
+// paused throughout. Discounts apply to a charge by the day and period
+// index it is billed on.
+func eachCharge(sub *domain.Subscription, w window, mode TotalMode, emit chargeFunc) {
+	if sub.Billing.Count <= 0 {
+		sub.Billing = domain.MonthlyBilling
+	}
+
+	switch mode {
+	case TotalModeMonthlyEquivalent:
+		monthlyEquivalentCharges(sub, w, emit)
+	case TotalModeProrated:
+		proratedCharges(sub, w, emit)
+	default:
+		billedCharges(sub, w, emit)
+	}
+}
+
+// activeUntil is the last day sub can be charged inside w.
+func activeUntil(sub *domain.Subscription, w window) time.Time {
+	end := w.to
+	if sub.EndDate != nil {
+		if se := truncateDay(*sub.EndDate); se.Before(end) {
+			end = se
+		}
+	}
+	return end
+}
+
+// segmentUntil is the last day seg can be charged on when the
+// subscription is charged until end.
+func segmentUntil(seg domain.BillingSegment, end time.Time) time.Time {
+	if seg.Until != nil {
+		return minTime(truncateDay(*seg.Until), end)
+	}
+	return end
+}
+
+func billedCharges(sub *domain.Subscription, w window, emit chargeFunc) {
+	end := activeUntil(sub, w)
+
+	for _, seg := range sub.Segments() {
+		start, until := truncateDay(seg.Anchor), segmentUntil(seg, end)
+
+		for k := 0; ; k++ {
+			d := seg.Billing.Nth(start, k)
+			if d.After(until) {
+				break
+			}
+			if d.Before(w.from) || sub.PausedOn(d) {
+				continue
+			}
+			gross := float64(sub.PriceOn(d))
+			emit(firstOfMonth(d), gross, sub.DiscountOn(d, seg.Offset+k, gross))
+		}
+	}
+}
+
+// monthlyEquivalentCharges spreads the price and interval in effect at
+// the start of each month, or on the billing anchor in the first one.
+// Months paused throughout are not charged.
+func monthlyEquivalentCharges(sub *domain.Subscription, w window, emit chargeFunc) {
+	segs := sub.Segments()
+	start := truncateDay(segs[0].Anchor)
+	end := activeUntil(sub, w)
+
+	first := firstOfMonth(start)
+	if from := firstOfMonth(w.from); first.Before(from) {
+		first = from
+	}
+	last := firstOfMonth(end)
+
+	// lo falls in the k-th billing period of segment i.
+	i, k := 0, 0
+	for m := first; !m.After(last); m = m.AddDate(0, 1, 0) {
+		lo := maxTime(m, start)
+		for i+1 < len(segs) && !truncateDay(segs[i+1].Anchor).After(lo) {
+			i, k = i+1, 0
+		}
+		seg := segs[i]
+		for !seg.Billing.Nth(truncateDay(seg.Anchor), k+1).After(lo) {
+			k++
+		}
+		if billedDays(sub, lo, minTime(lastOfMonth(m), end)) == 0 {
+			continue
+		}
+		// The discounts apply to the charge for the whole period, which
+		// is then spread like the price, so a fixed discount is taken off
+		// once per period rather than once per month.
+		price := float64(sub.PriceOn(lo))
+		months := seg.Billing.Months()
+		emit(m, price/months, sub.DiscountOn(lo, seg.Offset+k, price)/months)
+	}
+}
+
+// proratedCharges charges every billing period by the days used of it. A
+// plan change cuts the period it falls in short, so an upgrade only costs
+// the old plan up to the day before.
+func proratedCharges(sub *domain.Subscription, w window, emit chargeFunc) {
+	end := activeUntil(sub, w)
+
+	for _, seg := range sub.Segments() {
+		start, until := truncateDay(seg.Anchor), segmentUntil(seg, end)
+		from := maxTime(w.from, start)
+
+		for k := 0; ; k++ {
+			periodStart := seg.Billing.Nth(start, k)
+			if periodStart.After(until) {
+				break
+			}
+			periodEnd := seg.Billing.Nth(start, k+1).AddDate(0, 0, -1)
+			if periodEnd.Before(from) {
+				continue
+			}
+			prorate(sub, periodStart, periodEnd, maxTime(periodStart, from), minTime(periodEnd, until), seg.Offset+k, emit)
+		}
+	}
+}
+
+// prorate charges the days lo to hi of the n-th billing period, which runs
+// from periodStart to periodEnd.
+func prorate(sub *domain.Subscription, periodStart, periodEnd, lo, hi time.Time, n int, emit chargeFunc) {
+	// A period costs the price in effect when it is billed, less the
+	// discounts on that charge.
+	price := float64(sub.PriceOn(periodStart))
+	days := float64(daysInclusive(periodStart, periodEnd))
+	grossPerDay := price / days
+	discountPerDay := sub.DiscountOn(periodStart, n, price) / days
+
+	// Split the overlap by calendar month so each piece lands in the month
+	// it was consumed in.
+	for !lo.After(hi) {
+		pieceEnd := minTime(lastOfMonth(lo), hi)
+		if days := float64(billedDays(sub, lo, pieceEnd)); days > 0 {
+			emit(firstOfMonth(lo), grossPerDay*days, discountPerDay*days)
+		}
+		lo = pieceEnd.AddDate(0, 0, 1)
+	}
+}
+
+// billedDays counts the days from lo to hi that sub is not paused on.
+func billedDays(sub *domain.Subscription, lo, hi time.Time) int {
+	n := daysInclusive(lo, hi)
+	for _, p := range sub.Pauses {
+		pLo, pHi := maxTime(p.StartDate, lo), hi
+		if p.EndDate != nil {
+			pHi = minTime(*p.EndDate, hi)
+		}
+		if !pLo.After(pHi) {
+			n -= daysInclusive(pLo, pHi)
+		}
+	}
+	return n
+}
+
+func daysInclusive(from, to time.Time) int {
+	return int(to.Sub(from).Hours()/24) + 1
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func firstOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func lastOfMonth(t time.Time) time.Time {
+	return firstOfMonth(t).AddDate(0, 1, -1)
+}
+
+func truncateDay(t time.Time) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
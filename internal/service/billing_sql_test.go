@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+	"github.com/google/uuid"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// testDB connects to the database in TEST_DATABASE_URL, skipping the test
+// without one outside CI, and migrates a fresh schema that is dropped
+// afterwards.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("TEST_DATABASE_URL must be set in CI")
+		}
+		t.Skip("TEST_DATABASE_URL is not set; see make test-db")
+	}
+
+	admin, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Close() })
+
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec("CREATE SCHEMA " + schema); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { admin.Exec("DROP SCHEMA " + schema + " CASCADE") })
+
+	// Every pooled connection must see the schema first, so it goes into
+	// the connection string rather than a SET.
+	sep := " "
+	if strings.Contains(dsn, "://") {
+		sep = "&"
+		if !strings.Contains(dsn, "?") {
+			sep = "?"
+		}
+	}
+	db, err := sql.Open("pgx", dsn+sep+"search_path="+schema+",public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	files, err := filepath.Glob("../../migrations/*.up.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	sort.Strings(files)
+	for _, f := range files {
+		script, err := os.ReadFile(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := db.Exec(string(script)); err != nil {
+			t.Fatalf("%s: %v", filepath.Base(f), err)
+		}
+	}
+
+	return db
+}
+
+func date(y int, m time.Month, d int) time.Time {
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func datePtr(y int, m time.Month, d int) *time.Time {
+	t := date(y, m, d)
+	return &t
+}
+
+type monthCharge struct {
+	gross    float64
+	discount float64
+}
+
+// TestSumChargesMatchesBilledCharges checks that the billed totals the
+// repository computes in SQL are those of the in-memory engine.
+func TestSumChargesMatchesBilledCharges(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	subs := repo.NewSubscriptionPostgres(db)
+	services := repo.NewServicePostgres(db)
+
+	svc := domain.Service{Name: "Equivalence", DefaultCurrency: "RUB", DefaultBilling: domain.MonthlyBilling}
+	if err := services.Create(ctx, &svc); err != nil {
+		t.Fatal(err)
+	}
+	plan := domain.Plan{ServiceID: svc.ID, Name: "Any", Price: 1, Currency: "RUB", Billing: domain.MonthlyBilling}
+	if err := services.CreatePlan(ctx, &plan); err != nil {
+		t.Fatal(err)
+	}
+
+	monthly := domain.MonthlyBilling
+	quarterly := domain.BillingInterval{Unit: domain.BillingMonth, Count: 3}
+	yearly := domain.BillingInterval{Unit: domain.BillingYear, Count: 1}
+	biweekly := domain.BillingInterval{Unit: domain.BillingWeek, Count: 2}
+
+	planChange := func(from time.Time, price int, billing domain.BillingInterval) domain.PlanChange {
+		return domain.PlanChange{EffectiveFrom: from, PlanID: &plan.ID, PlanName: plan.Name, Price: price, Billing: billing}
+	}
+
+	tests := []struct {
+		name string
+		sub  domain.Subscription
+	}{
+		{
+			name: "month end clamping",
+			sub:  domain.Subscription{Price: 500, Billing: monthly, StartDate: date(2024, time.January, 31)},
+		},
+		{
+			name: "quarterly from month end until end date",
+			sub: domain.Subscription{
+				Price: 900, Billing: quarterly,
+				StartDate: date(2024, time.August, 31), EndDate: datePtr(2025, time.August, 30),
+			},
+		},
+		{
+			name: "trial",
+			sub: domain.Subscription{
+				Price: 300, Billing: monthly,
+				StartDate: date(2024, time.January, 10), TrialEndDate: datePtr(2024, time.February, 29),
+			},
+		},
+		{
+			name: "pauses",
+			sub: domain.Subscription{
+				Price: 400, Billing: biweekly, StartDate: date(2024, time.January, 5),
+				Pauses: []domain.Pause{
+					{StartDate: date(2024, time.March, 1), EndDate: datePtr(2024, time.April, 30)},
+					{StartDate: date(2025, time.September, 1)},
+				},
+			},
+		},
+		{
+			name: "price changes",
+			sub: domain.Subscription{
+				Price: 500, Billing: monthly, StartDate: date(2024, time.January, 31),
+				PriceChanges: []domain.PriceChange{
+					{EffectiveFrom: date(2024, time.March, 5), Price: 700},
+					{EffectiveFrom: date(2024, time.June, 30), Price: 300},
+				},
+			},
+		},
+		{
+			name: "plan changes",
+			sub: domain.Subscription{
+				Price: 500, Billing: monthly, StartDate: date(2024, time.January, 31),
+				PriceChanges: []domain.PriceChange{
+					{EffectiveFrom: date(2024, time.May, 1), Price: 550},
+				},
+				PlanChanges: []domain.PlanChange{
+					planChange(date(2024, time.April, 20), 5000, yearly),
+					planChange(date(2025, time.February, 10), 250, biweekly),
+				},
+			},
+		},
+		{
+			name: "plan change during trial",
+			sub: domain.Subscription{
+				Price: 500, Billing: monthly,
+				StartDate: date(2024, time.January, 15), TrialEndDate: datePtr(2024, time.February, 14),
+				PlanChanges: []domain.PlanChange{
+					planChange(date(2024, time.January, 20), 1200, quarterly),
+				},
+			},
+		},
+		{
+			name: "fixed and percent discounts",
+			sub: domain.Subscription{
+				Price: 1000, Billing: monthly, StartDate: date(2024, time.January, 31),
+				Discounts: []domain.Discount{
+					{Kind: domain.DiscountPercent, Value: 20, Periods: 3},
+					{Kind: domain.DiscountFixed, Value: 150, StartDate: datePtr(2024, time.March, 1), EndDate: datePtr(2024, time.August, 31)},
+					{Kind: domain.DiscountFixed, Value: 2000, StartDate: datePtr(2025, time.June, 1), EndDate: datePtr(2025, time.June, 30)},
+				},
+			},
+		},
+		{
+			name: "discount periods across plan changes",
+			sub: domain.Subscription{
+				Price: 300, Billing: monthly,
+				StartDate: date(2024, time.February, 1), TrialEndDate: datePtr(2024, time.February, 14),
+				PlanChanges: []domain.PlanChange{
+					planChange(date(2024, time.April, 1), 200, biweekly),
+				},
+				Pauses: []domain.Pause{
+					{StartDate: date(2024, time.May, 1), EndDate: datePtr(2024, time.May, 31)},
+				},
+				Discounts: []domain.Discount{
+					{Kind: domain.DiscountPercent, Value: 50, Periods: 6},
+					{Kind: domain.DiscountFixed, Value: 25, StartDate: datePtr(2024, time.April, 15)},
+				},
+			},
+		},
+	}
+
+	w := window{from: date(2024, time.January, 1), to: date(2025, time.December, 31)}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := tt.sub
+			in.UserID = uuid.New()
+			in.ServiceName = svc.Name
+			in.Currency = "RUB"
+			if err := subs.Create(ctx, &in); err != nil {
+				t.Fatal(err)
+			}
+			for _, pc := range in.PriceChanges {
+				if err := subs.SetPriceChange(ctx, in.ID, pc); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, pc := range in.PlanChanges {
+				if err := subs.SetPlanChange(ctx, in.ID, pc); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, p := range in.Pauses {
+				if err := subs.AddPause(ctx, in.ID, p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for i := range in.Discounts {
+				if err := subs.AddDiscount(ctx, in.ID, &in.Discounts[i]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			sub, err := subs.GetByID(ctx, in.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := map[time.Time]monthCharge{}
+			eachCharge(sub, w, TotalModeBilled, func(month time.Time, gross, discount float64) {
+				c := want[month]
+				c.gross += gross
+				c.discount += discount
+				want[month] = c
+			})
+			if len(want) == 0 {
+				t.Fatal("the case charges nothing")
+			}
+
+			sums, err := subs.SumCharges(ctx, repo.ChargeFilter{UserID: &in.UserID, From: w.from, To: w.to})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[time.Time]monthCharge{}
+			for _, cs := range sums {
+				got[firstOfMonth(cs.Month.UTC())] = monthCharge{gross: cs.Gross, discount: cs.Discount}
+			}
+
+			for _, month := range unionMonths(want, got) {
+				if w, g := want[month], got[month]; !closeTo(w.gross, g.gross) || !closeTo(w.discount, g.discount) {
+					t.Errorf("%s: got gross %v discount %v, want gross %v discount %v",
+						month.Format("2006-01"), g.gross, g.discount, w.gross, w.discount)
+				}
+			}
+		})
+	}
+}
+
+func unionMonths(a, b map[time.Time]monthCharge) []time.Time {
+	seen := map[time.Time]bool{}
+	var months []time.Time
+	for _, m := range []map[time.Time]monthCharge{a, b} {
+		for month := range m {
+			if !seen[month] {
+				seen[month] = true
+				months = append(months, month)
+			}
+		}
+	}
+	sort.Slice(months, func(i, j int) bool { return months[i].Before(months[j]) })
+	return months
+}
+
+func closeTo(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
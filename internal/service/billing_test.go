@@ -0,0 +1,151 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// TestFixedDiscountSpreadsOverPeriod checks that every mode takes a fixed
+// discount off a long billing period once, however the period is split.
+func TestFixedDiscountSpreadsOverPeriod(t *testing.T) {
+	sub := domain.Subscription{
+		Price:     1200,
+		Currency:  "RUB",
+		Billing:   domain.BillingInterval{Unit: domain.BillingYear, Count: 1},
+		StartDate: date(2024, time.January, 1),
+		Discounts: []domain.Discount{{Kind: domain.DiscountFixed, Value: 100, Periods: 1}},
+	}
+	w := window{from: date(2024, time.January, 1), to: date(2024, time.December, 31)}
+
+	for _, mode := range []TotalMode{TotalModeBilled, TotalModeMonthlyEquivalent, TotalModeProrated} {
+		t.Run(string(mode), func(t *testing.T) {
+			var gross, discount float64
+			s := sub
+			eachCharge(&s, w, mode, func(_ time.Time, g, d float64) {
+				gross += g
+				discount += d
+			})
+			if !closeTo(gross, 1200) || !closeTo(discount, 100) {
+				t.Errorf("got gross %v discount %v, want gross 1200 discount 100", gross, discount)
+			}
+		})
+	}
+}
+
+type monthly map[time.Time]monthCharge
+
+func TestEachCharge(t *testing.T) {
+	month := func(y int, m time.Month) time.Time { return date(y, m, 1) }
+
+	tests := []struct {
+		name string
+		sub  domain.Subscription
+		w    window
+		want map[TotalMode]monthly
+	}{
+		{
+			name: "month end is clamped",
+			sub:  domain.Subscription{Price: 300, Billing: domain.MonthlyBilling, StartDate: date(2024, time.January, 31)},
+			w:    window{from: date(2024, time.January, 1), to: date(2024, time.April, 30)},
+			want: map[TotalMode]monthly{
+				// Billed on Jan 31, Feb 29, Mar 31 and Apr 30.
+				TotalModeBilled: {
+					month(2024, time.January): {gross: 300}, month(2024, time.February): {gross: 300},
+					month(2024, time.March): {gross: 300}, month(2024, time.April): {gross: 300},
+				},
+				TotalModeMonthlyEquivalent: {
+					month(2024, time.January): {gross: 300}, month(2024, time.February): {gross: 300},
+					month(2024, time.March): {gross: 300}, month(2024, time.April): {gross: 300},
+				},
+				// Periods of 29, 31, 30 and 31 days, split at month ends.
+				TotalModeProrated: {
+					month(2024, time.January):  {gross: 300.0 / 29},
+					month(2024, time.February): {gross: 300.0*28/29 + 300.0/31},
+					month(2024, time.March):    {gross: 300.0*30/31 + 10},
+					month(2024, time.April):    {gross: 290 + 300.0/31},
+				},
+			},
+		},
+		{
+			name: "weeks across a month boundary",
+			sub:  domain.Subscription{Price: 140, Billing: domain.BillingInterval{Unit: domain.BillingWeek, Count: 2}, StartDate: date(2024, time.January, 25)},
+			w:    window{from: date(2024, time.January, 1), to: date(2024, time.February, 29)},
+			want: map[TotalMode]monthly{
+				// Billed on Jan 25, Feb 8 and Feb 22.
+				TotalModeBilled: {month(2024, time.January): {gross: 140}, month(2024, time.February): {gross: 280}},
+				TotalModeMonthlyEquivalent: {
+					month(2024, time.January): {gross: 140 * 52.0 / 24}, month(2024, time.February): {gross: 140 * 52.0 / 24},
+				},
+				// 10 a day.
+				TotalModeProrated: {month(2024, time.January): {gross: 70}, month(2024, time.February): {gross: 290}},
+			},
+		},
+		{
+			name: "quarterly until an end date",
+			sub: domain.Subscription{
+				Price: 900, Billing: domain.BillingInterval{Unit: domain.BillingMonth, Count: 3},
+				StartDate: date(2024, time.November, 30), EndDate: datePtr(2025, time.March, 15),
+			},
+			w: window{from: date(2024, time.November, 1), to: date(2025, time.June, 30)},
+			want: map[TotalMode]monthly{
+				// Billed on Nov 30 and Feb 28.
+				TotalModeBilled: {month(2024, time.November): {gross: 900}, month(2025, time.February): {gross: 900}},
+				TotalModeMonthlyEquivalent: {
+					month(2024, time.November): {gross: 300}, month(2024, time.December): {gross: 300},
+					month(2025, time.January): {gross: 300}, month(2025, time.February): {gross: 300},
+					month(2025, time.March): {gross: 300},
+				},
+				// The first period has 90 days, the second 91 of which 16
+				// are used.
+				TotalModeProrated: {
+					month(2024, time.November): {gross: 10}, month(2024, time.December): {gross: 310},
+					month(2025, time.January): {gross: 310}, month(2025, time.February): {gross: 270 + 900.0/91},
+					month(2025, time.March): {gross: 900.0 * 15 / 91},
+				},
+			},
+		},
+		{
+			name: "trial, pause and discounted periods",
+			sub: domain.Subscription{
+				Price: 100, Billing: domain.MonthlyBilling,
+				StartDate: date(2024, time.January, 10), TrialEndDate: datePtr(2024, time.January, 31),
+				Pauses:    []domain.Pause{{StartDate: date(2024, time.March, 1), EndDate: datePtr(2024, time.March, 31)}},
+				Discounts: []domain.Discount{{Kind: domain.DiscountPercent, Value: 10, Periods: 2}},
+			},
+			w: window{from: date(2024, time.January, 1), to: date(2024, time.May, 31)},
+			// The paused March charge is the second discounted period.
+			want: func() map[TotalMode]monthly {
+				m := monthly{
+					month(2024, time.February): {gross: 100, discount: 10},
+					month(2024, time.April):    {gross: 100},
+					month(2024, time.May):      {gross: 100},
+				}
+				return map[TotalMode]monthly{TotalModeBilled: m, TotalModeMonthlyEquivalent: m, TotalModeProrated: m}
+			}(),
+		},
+	}
+
+	for _, tt := range tests {
+		for mode, want := range tt.want {
+			t.Run(tt.name+"/"+string(mode), func(t *testing.T) {
+				sub := tt.sub
+				got := monthly{}
+				eachCharge(&sub, tt.w, mode, func(m time.Time, gross, discount float64) {
+					c := got[m]
+					c.gross += gross
+					c.discount += discount
+					got[m] = c
+				})
+
+				for _, m := range unionMonths(want, got) {
+					if w, g := want[m], got[m]; !closeTo(w.gross, g.gross) || !closeTo(w.discount, g.discount) {
+						t.Errorf("%s: got gross %v discount %v, want gross %v discount %v",
+							m.Format("2006-01"), g.gross, g.discount, w.gross, w.discount)
+					}
+				}
+			})
+		}
+	}
+}
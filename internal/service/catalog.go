@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// CatalogService manages the service catalog. Subscriptions naming a
+// catalog service by its name or an alias are linked to it on write.
+type CatalogService interface {
+	Create(ctx context.Context, svc *domain.Service) error
+	GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error)
+	// Update replaces the service; a new name is carried over to its
+	// subscriptions, each recording the change in its history. Deleted
+	// subscriptions keep their name.
+	Update(ctx context.Context, svc *domain.Service) error
+	// Delete fails with domain.ErrConflict while subscriptions refer to
+	// the service.
+	Delete(ctx context.Context, id uuid.UUID) error
+	List(ctx context.Context, f CatalogFilter) ([]domain.Service, error)
+
+	// CreatePlan adds a plan to p.ServiceID. Editing a plan only affects
+	// subscriptions put on it afterwards.
+	CreatePlan(ctx context.Context, p *domain.Plan) error
+	UpdatePlan(ctx context.Context, p *domain.Plan) error
+	// DeletePlan fails with domain.ErrConflict while subscriptions refer to
+	// the plan.
+	DeletePlan(ctx context.Context, serviceID, planID uuid.UUID) error
+}
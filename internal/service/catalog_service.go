@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+type catalogService struct {
+	repo   repo.ServiceRepository
+	subs   repo.SubscriptionRepository
+	events repo.SubscriptionEventRepository
+	tx     repo.TxManager
+}
+
+func NewCatalogService(
+	r repo.ServiceRepository,
+	subs repo.SubscriptionRepository,
+	events repo.SubscriptionEventRepository,
+	tx repo.TxManager,
+) CatalogService {
+	return &catalogService{repo: r, subs: subs, events: events, tx: tx}
+}
+
+// validateService normalizes svc: names are trimmed and aliases that repeat
+// the name or each other are dropped.
+func validateService(svc *domain.Service) error {
+	verr := domain.NewValidationError()
+
+	svc.Name = strings.TrimSpace(svc.Name)
+	svc.Category = strings.TrimSpace(svc.Category)
+	if svc.Name == "" {
+		verr.Add("name", "required", "is required")
+	}
+
+	seen := map[string]bool{domain.ServiceKey(svc.Name): true}
+	aliases := svc.Aliases[:0]
+	for i, a := range svc.Aliases {
+		a = strings.TrimSpace(a)
+		if a == "" {
+			verr.Add(fmt.Sprintf("aliases[%d]", i), "required", "must not be empty")
+			continue
+		}
+		if key := domain.ServiceKey(a); !seen[key] {
+			seen[key] = true
+			aliases = append(aliases, a)
+		}
+	}
+	svc.Aliases = aliases
+
+	svc.DefaultCurrency = domain.NormalizeCurrency(svc.DefaultCurrency)
+	if svc.DefaultPrice != nil && svc.DefaultCurrency == "" {
+		svc.DefaultCurrency = domain.DefaultCurrency
+	}
+	if svc.DefaultBilling.Unit == "" {
+		svc.DefaultBilling.Unit = domain.MonthlyBilling.Unit
+	}
+	if svc.DefaultBilling.Count == 0 {
+		svc.DefaultBilling.Count = domain.MonthlyBilling.Count
+	}
+
+	if svc.DefaultPrice != nil && *svc.DefaultPrice <= 0 {
+		verr.Add("default_price", "must_be_positive", "must be greater than 0")
+	}
+	if svc.DefaultCurrency != "" {
+		validateCurrency(verr, "default_currency", svc.DefaultCurrency)
+	}
+	if !svc.DefaultBilling.Unit.Valid() {
+		verr.Add("default_billing_unit", "invalid_billing_unit", "must be one of week, month, year")
+	}
+	if svc.DefaultBilling.Count < 0 {
+		verr.Add("default_billing_count", "must_be_positive", "must be greater than 0")
+	}
+
+	return verr.Err()
+}
+
+func (s *catalogService) Create(ctx context.Context, svc *domain.Service) error {
+	if err := validateService(svc); err != nil {
+		return err
+	}
+	return s.repo.Create(ctx, svc)
+}
+
+func (s *catalogService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	return s.repo.GetByID(ctx, id)
+}
+
+func (s *catalogService) Update(ctx context.Context, svc *domain.Service) error {
+	if err := validateService(svc); err != nil {
+		return err
+	}
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, svc); err != nil {
+			return err
+		}
+		return s.renameSubscriptions(ctx, svc)
+	})
+}
+
+// renameSubscriptions carries the name of svc over to the subscriptions
+// linked to it, recording each as an update. Deleted subscriptions keep
+// their name.
+func (s *catalogService) renameSubscriptions(ctx context.Context, svc *domain.Service) error {
+	subs, err := s.subs.List(ctx, repo.ListFilter{ServiceID: &svc.ID})
+	if err != nil {
+		return err
+	}
+
+	for _, listed := range subs {
+		if listed.ServiceName == svc.Name {
+			continue
+		}
+		sub, err := s.subs.GetForUpdate(ctx, listed.ID)
+		if err != nil {
+			return err
+		}
+		before := *sub
+
+		sub.ServiceName = svc.Name
+		if err := s.subs.Update(ctx, sub); err != nil {
+			return err
+		}
+		if err := recordEvent(ctx, s.events, domain.EventUpdated, &before, sub); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *catalogService) Delete(ctx context.Context, id uuid.UUID) error {
+	return s.repo.Delete(ctx, id)
+}
+
+func (s *catalogService) List(ctx context.Context, f CatalogFilter) ([]domain.Service, error) {
+	return s.repo.List(ctx, repo.ServiceFilter{
+		Category: f.Category,
+		Search:   f.Search,
+	})
+}
+
+// validatePlan normalizes p; its currency defaults to that of svc.
+func validatePlan(p *domain.Plan, svc *domain.Service) error {
+	verr := domain.NewValidationError()
+
+	p.Name = strings.TrimSpace(p.Name)
+	p.Currency = domain.NormalizeCurrency(p.Currency)
+	if p.Currency == "" {
+		p.Currency = svc.DefaultCurrency
+	}
+	if p.Currency == "" {
+		p.Currency = domain.DefaultCurrency
+	}
+	if p.Billing.Unit == "" {
+		p.Billing.Unit = domain.MonthlyBilling.Unit
+	}
+	if p.Billing.Count == 0 {
+		p.Billing.Count = domain.MonthlyBilling.Count
+	}
+
+	if p.Name == "" {
+		verr.Add("name", "required", "is required")
+	}
+	if p.Price <= 0 {
+		verr.Add("price", "must_be_positive", "must be greater than 0")
+	}
+	validateCurrency(verr, "currency", p.Currency)
+	if !p.Billing.Unit.Valid() {
+		verr.Add("billing_unit", "invalid_billing_unit", "must be one of week, month, year")
+	}
+	if p.Billing.Count < 0 {
+		verr.Add("billing_count", "must_be_positive", "must be greater than 0")
+	}
+
+	return verr.Err()
+}
+
+func (s *catalogService) CreatePlan(ctx context.Context, p *domain.Plan) error {
+	svc, err := s.repo.GetByID(ctx, p.ServiceID)
+	if err != nil {
+		return err
+	}
+	if err := validatePlan(p, svc); err != nil {
+		return err
+	}
+	return s.repo.CreatePlan(ctx, p)
+}
+
+func (s *catalogService) UpdatePlan(ctx context.Context, p *domain.Plan) error {
+	svc, err := s.repo.GetByID(ctx, p.ServiceID)
+	if err != nil {
+		return err
+	}
+	if err := validatePlan(p, svc); err != nil {
+		return err
+	}
+	return s.repo.UpdatePlan(ctx, p)
+}
+
+func (s *catalogService) DeletePlan(ctx context.Context, serviceID, planID uuid.UUID) error {
+	return s.repo.DeletePlan(ctx, serviceID, planID)
+}
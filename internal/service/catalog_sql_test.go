@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+// TestDeleteServiceUnlinksDeletedSubscriptions checks that only
+// subscriptions outside the trash keep a service in the catalog.
+func TestDeleteServiceUnlinksDeletedSubscriptions(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	subs := repo.NewSubscriptionPostgres(db)
+	services := repo.NewServicePostgres(db)
+
+	svc := domain.Service{Name: "Doomed", DefaultCurrency: "RUB", DefaultBilling: domain.MonthlyBilling}
+	if err := services.Create(ctx, &svc); err != nil {
+		t.Fatal(err)
+	}
+	plan := domain.Plan{ServiceID: svc.ID, Name: "Basic", Price: 100, Currency: "RUB", Billing: domain.MonthlyBilling}
+	if err := services.CreatePlan(ctx, &plan); err != nil {
+		t.Fatal(err)
+	}
+
+	sub := domain.Subscription{
+		UserID: uuid.New(), ServiceName: svc.Name, ServiceID: &svc.ID, PlanID: &plan.ID,
+		Price: 100, Currency: "RUB", Billing: domain.MonthlyBilling, StartDate: date(2024, time.January, 1),
+	}
+	if err := subs.Create(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	change := domain.PlanChange{EffectiveFrom: date(2024, time.March, 1), PlanID: &plan.ID, PlanName: plan.Name, Price: 150, Billing: plan.Billing}
+	if err := subs.SetPlanChange(ctx, sub.ID, change); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := services.Delete(ctx, svc.ID); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("deleting a service in use: got %v, want a conflict", err)
+	}
+
+	current, err := subs.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := subs.Delete(ctx, sub.ID, current.Version); err != nil {
+		t.Fatal(err)
+	}
+	if err := services.Delete(ctx, svc.ID); err != nil {
+		t.Fatalf("deleting a service only the trash refers to: %v", err)
+	}
+
+	if _, err := subs.Restore(ctx, sub.ID); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := subs.GetByID(ctx, sub.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.ServiceID != nil || restored.PlanID != nil || restored.ServiceName != svc.Name {
+		t.Errorf("got service %v plan %v name %q, want no service or plan and name %q",
+			restored.ServiceID, restored.PlanID, restored.ServiceName, svc.Name)
+	}
+	if len(restored.PlanChanges) != 1 || restored.PlanChanges[0].PlanID != nil || restored.PlanChanges[0].PlanName != plan.Name {
+		t.Errorf("got plan changes %+v, want one keeping the plan name but not the plan", restored.PlanChanges)
+	}
+}
@@ -0,0 +1,175 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestValidateService(t *testing.T) {
+	price := func(p int) *int { return &p }
+
+	tests := []struct {
+		name  string
+		svc   domain.Service
+		want  domain.Service
+		codes []string
+	}{
+		{
+			name: "trimmed, with monthly billing by default",
+			svc:  domain.Service{Name: "  Yandex Plus ", Category: " music "},
+			want: domain.Service{Name: "Yandex Plus", Category: "music", Aliases: []string{}, DefaultBilling: domain.MonthlyBilling},
+		},
+		{
+			name: "aliases spelling the name or each other are dropped",
+			svc:  domain.Service{Name: "Yandex Plus", Aliases: []string{"yandex  plus", " Плюс ", "Яндекс Плюс", "плюс"}},
+			want: domain.Service{Name: "Yandex Plus", Aliases: []string{"Плюс", "Яндекс Плюс"}, DefaultBilling: domain.MonthlyBilling},
+		},
+		{
+			name: "a default price is in the default currency",
+			svc:  domain.Service{Name: "Netflix", DefaultPrice: price(799)},
+			want: domain.Service{Name: "Netflix", Aliases: []string{}, DefaultPrice: price(799), DefaultCurrency: domain.DefaultCurrency, DefaultBilling: domain.MonthlyBilling},
+		},
+		{
+			name: "currency normalized",
+			svc:  domain.Service{Name: "Netflix", DefaultCurrency: " usd ", DefaultBilling: domain.BillingInterval{Unit: domain.BillingYear}},
+			want: domain.Service{Name: "Netflix", Aliases: []string{}, DefaultCurrency: "USD", DefaultBilling: domain.BillingInterval{Unit: domain.BillingYear, Count: 1}},
+		},
+		{
+			name:  "no name",
+			svc:   domain.Service{Name: "  "},
+			codes: []string{"required"},
+		},
+		{
+			name:  "empty alias",
+			svc:   domain.Service{Name: "Netflix", Aliases: []string{"NF", " "}},
+			codes: []string{"required"},
+		},
+		{
+			name:  "price not positive",
+			svc:   domain.Service{Name: "Netflix", DefaultPrice: price(0)},
+			codes: []string{"must_be_positive"},
+		},
+		{
+			name:  "bad billing",
+			svc:   domain.Service{Name: "Netflix", DefaultBilling: domain.BillingInterval{Unit: "day", Count: -1}},
+			codes: []string{"invalid_billing_unit", "must_be_positive"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := tt.svc
+			err := validateService(&svc)
+
+			var verr *domain.ValidationError
+			if err != nil && !errors.As(err, &verr) {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+			assertCodes(t, verr, tt.codes...)
+			if tt.codes != nil {
+				return
+			}
+
+			if svc.Name != tt.want.Name || svc.Category != tt.want.Category || !slices.Equal(svc.Aliases, tt.want.Aliases) {
+				t.Errorf("got name %q category %q aliases %q, want %q %q %q",
+					svc.Name, svc.Category, svc.Aliases, tt.want.Name, tt.want.Category, tt.want.Aliases)
+			}
+			if !equalPrice(svc.DefaultPrice, tt.want.DefaultPrice) || svc.DefaultCurrency != tt.want.DefaultCurrency || svc.DefaultBilling != tt.want.DefaultBilling {
+				t.Errorf("got defaults %v %q %v, want %v %q %v",
+					svc.DefaultPrice, svc.DefaultCurrency, svc.DefaultBilling, tt.want.DefaultPrice, tt.want.DefaultCurrency, tt.want.DefaultBilling)
+			}
+		})
+	}
+}
+
+func equalPrice(a, b *int) bool {
+	return a == nil && b == nil || a != nil && b != nil && *a == *b
+}
+
+func TestResolveService(t *testing.T) {
+	price := 299
+	yearly := domain.BillingInterval{Unit: domain.BillingYear, Count: 1}
+	plus := domain.Service{
+		ID: uuid.New(), Name: "Yandex Plus", Aliases: []string{"Плюс"},
+		DefaultPrice: &price, DefaultCurrency: "RUB", DefaultBilling: domain.MonthlyBilling,
+	}
+	netflix := domain.Service{ID: uuid.New(), Name: "Netflix", DefaultBilling: domain.MonthlyBilling}
+	family := domain.Plan{ID: uuid.New(), ServiceID: plus.ID, Name: "Family", Price: 3990, Currency: "RUB", Billing: yearly}
+	catalog := &memCatalog{services: []domain.Service{plus, netflix}, plans: []domain.Plan{family}}
+	unknown := uuid.New()
+
+	tests := []struct {
+		name  string
+		sub   domain.Subscription
+		want  domain.Subscription
+		codes []string
+	}{
+		{
+			name: "linked by name, whatever the spelling, with the defaults",
+			sub:  domain.Subscription{ServiceName: " yandex   PLUS"},
+			want: domain.Subscription{ServiceID: &plus.ID, ServiceName: "Yandex Plus", Price: 299, Currency: "RUB", Billing: domain.MonthlyBilling},
+		},
+		{
+			name: "linked by alias, keeping what is set",
+			sub:  domain.Subscription{ServiceName: "плюс", Price: 199, Currency: "USD", Billing: yearly},
+			want: domain.Subscription{ServiceID: &plus.ID, ServiceName: "Yandex Plus", Price: 199, Currency: "USD", Billing: yearly},
+		},
+		{
+			name: "linked by id, whatever the name",
+			sub:  domain.Subscription{ServiceID: &netflix.ID, ServiceName: "NF", Price: 799, Currency: "RUB"},
+			want: domain.Subscription{ServiceID: &netflix.ID, ServiceName: "Netflix", Price: 799, Currency: "RUB", Billing: domain.MonthlyBilling},
+		},
+		{
+			name: "linked by plan, with its price and interval",
+			sub:  domain.Subscription{PlanID: &family.ID},
+			want: domain.Subscription{ServiceID: &plus.ID, PlanID: &family.ID, ServiceName: "Yandex Plus", Price: 3990, Currency: "RUB", Billing: yearly},
+		},
+		{
+			name: "unknown name stays free text",
+			sub:  domain.Subscription{ServiceName: "Kinopoisk", Price: 100, Currency: "RUB"},
+			want: domain.Subscription{ServiceName: "Kinopoisk", Price: 100, Currency: "RUB"},
+		},
+		{
+			name:  "unknown id",
+			sub:   domain.Subscription{ServiceID: &unknown, ServiceName: "Yandex Plus"},
+			codes: []string{"unknown_service"},
+		},
+		{
+			name:  "plan of another service",
+			sub:   domain.Subscription{ServiceID: &netflix.ID, PlanID: &family.ID},
+			codes: []string{"other_service"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := newTestService(newMemSubscriptions(), catalog, &fakeTx{})
+			sub := tt.sub
+			err := s.resolveService(context.Background(), &sub)
+
+			var verr *domain.ValidationError
+			if err != nil && !errors.As(err, &verr) {
+				t.Fatalf("got %v, want a validation error", err)
+			}
+			assertCodes(t, verr, tt.codes...)
+			if tt.codes != nil {
+				return
+			}
+
+			if !sameID(sub.ServiceID, tt.want.ServiceID) || !sameID(sub.PlanID, tt.want.PlanID) || sub.ServiceName != tt.want.ServiceName {
+				t.Errorf("got service %v plan %v name %q, want %v %v %q",
+					sub.ServiceID, sub.PlanID, sub.ServiceName, tt.want.ServiceID, tt.want.PlanID, tt.want.ServiceName)
+			}
+			if sub.Price != tt.want.Price || sub.Currency != tt.want.Currency || sub.Billing != tt.want.Billing {
+				t.Errorf("got %d %s %v, want %d %s %v",
+					sub.Price, sub.Currency, sub.Billing, tt.want.Price, tt.want.Currency, tt.want.Billing)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+var ErrNoExchangeRate = errors.New("no exchange rate")
+
+type rateConverter struct {
+	repo repo.ExchangeRateRepository
+}
+
+// NewCurrencyConverter returns a converter backed by the stored rate
+// history. A pair may be converted through its own rates or through the
+// inverse of the opposite pair; when both exist the more recent one wins.
+func NewCurrencyConverter(r repo.ExchangeRateRepository) CurrencyConverter {
+	return &rateConverter{repo: r}
+}
+
+func (c *rateConverter) Convert(ctx context.Context, amounts []DatedAmount, target string) ([]float64, error) {
+	latest := make(map[string]time.Time)
+	for _, a := range amounts {
+		if a.Currency == target {
+			continue
+		}
+		if a.Date.After(latest[a.Currency]) {
+			latest[a.Currency] = a.Date
+		}
+	}
+
+	series := make(map[string]*rateSeries, len(latest))
+	for cur, until := range latest {
+		direct, err := c.repo.History(ctx, cur, target, until)
+		if err != nil {
+			return nil, err
+		}
+		inverse, err := c.repo.History(ctx, target, cur, until)
+		if err != nil {
+			return nil, err
+		}
+		series[cur] = &rateSeries{direct: direct, inverse: inverse}
+	}
+
+	res := make([]float64, len(amounts))
+	for i, a := range amounts {
+		if a.Currency == target {
+			res[i] = a.Amount
+			continue
+		}
+
+		rate, ok := series[a.Currency].at(a.Date)
+		if !ok {
+			return nil, fmt.Errorf("%w for %s/%s on %s", ErrNoExchangeRate, a.Currency, target, a.Date.Format(time.DateOnly))
+		}
+		res[i] = a.Amount * rate
+	}
+
+	return res, nil
+}
+
+// rateSeries holds the date-ordered history of a pair and its inverse.
+type rateSeries struct {
+	direct  []domain.ExchangeRate
+	inverse []domain.ExchangeRate
+}
+
+func (s *rateSeries) at(date time.Time) (float64, bool) {
+	d, dok := latestOn(s.direct, date)
+	i, iok := latestOn(s.inverse, date)
+
+	switch {
+	case dok && (!iok || !i.Date.After(d.Date)):
+		return d.Rate, true
+	case iok:
+		return 1 / i.Rate, true
+	default:
+		return 0, false
+	}
+}
+
+func latestOn(rates []domain.ExchangeRate, date time.Time) (domain.ExchangeRate, bool) {
+	n := sort.Search(len(rates), func(i int) bool {
+		return rates[i].Date.After(date)
+	})
+	if n == 0 {
+		return domain.ExchangeRate{}, false
+	}
+	return rates[n-1], true
+}
@@ -0,0 +1,29 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+type ExchangeRateService interface {
+	Set(ctx context.Context, r *domain.ExchangeRate) error
+	// Import validates and stores a batch of rates atomically.
+	Import(ctx context.Context, rates []domain.ExchangeRate) error
+	Delete(ctx context.Context, base, quote string, date *time.Time) error
+	List(ctx context.Context, f ExchangeRateFilter) ([]domain.ExchangeRate, error)
+}
+
+// DatedAmount is an amount to be converted at the rate in effect on Date.
+type DatedAmount struct {
+	Date     time.Time
+	Amount   float64
+	Currency string
+}
+
+type CurrencyConverter interface {
+	// Convert converts every amount into target at the rate in effect on
+	// its date and returns the results in the same order.
+	Convert(ctx context.Context, amounts []DatedAmount, target string) ([]float64, error)
+}
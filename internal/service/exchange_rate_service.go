@@ -0,0 +1,87 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+type exchangeRateService struct {
+	repo repo.ExchangeRateRepository
+}
+
+func NewExchangeRateService(r repo.ExchangeRateRepository) ExchangeRateService {
+	return &exchangeRateService{repo: r}
+}
+
+func validateCurrency(verr *domain.ValidationError, field, code string) {
+	if !domain.IsCurrency(code) {
+		verr.Add(field, "invalid_currency", "must be an ISO 4217 code")
+	}
+}
+
+// validateRate normalizes r and reports problems with field names prefixed
+// by prefix, so batch imports can point at the offending row.
+func validateRate(verr *domain.ValidationError, prefix string, r *domain.ExchangeRate) {
+	r.Base = domain.NormalizeCurrency(r.Base)
+	r.Quote = domain.NormalizeCurrency(r.Quote)
+	r.Date = truncateDay(r.Date)
+
+	validateCurrency(verr, prefix+"base", r.Base)
+	validateCurrency(verr, prefix+"quote", r.Quote)
+	if r.Base == r.Quote {
+		verr.Add(prefix+"quote", "same_currency", "must differ from base")
+	}
+	if r.Date.IsZero() {
+		verr.Add(prefix+"date", "required", "is required")
+	}
+	// NaN compares false to everything, so it is caught before the sign.
+	switch {
+	case math.IsNaN(r.Rate) || math.IsInf(r.Rate, 0):
+		verr.Add(prefix+"rate", "invalid_format", "must be a finite number")
+	case r.Rate <= 0:
+		verr.Add(prefix+"rate", "must_be_positive", "must be greater than 0")
+	}
+}
+
+func (s *exchangeRateService) Set(ctx context.Context, r *domain.ExchangeRate) error {
+	verr := domain.NewValidationError()
+	validateRate(verr, "", r)
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	return s.repo.Upsert(ctx, r)
+}
+
+func (s *exchangeRateService) Import(ctx context.Context, rates []domain.ExchangeRate) error {
+	verr := domain.NewValidationError()
+	if len(rates) == 0 {
+		verr.Add("rates", "required", "must contain at least one rate")
+	}
+	for i := range rates {
+		validateRate(verr, fmt.Sprintf("rates[%d].", i), &rates[i])
+	}
+	if err := verr.Err(); err != nil {
+		return err
+	}
+
+	return s.repo.UpsertMany(ctx, rates)
+}
+
+func (s *exchangeRateService) Delete(ctx context.Context, base, quote string, date *time.Time) error {
+	return s.repo.Delete(ctx, domain.NormalizeCurrency(base), domain.NormalizeCurrency(quote), date)
+}
+
+func (s *exchangeRateService) List(ctx context.Context, f ExchangeRateFilter) ([]domain.ExchangeRate, error) {
+	return s.repo.List(ctx, repo.ExchangeRateFilter{
+		Base:  f.Base,
+		Quote: f.Quote,
+		From:  f.From,
+		To:    f.To,
+	})
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestValidateRate(t *testing.T) {
+	tests := []struct {
+		rate     float64
+		wantCode string
+	}{
+		{1.25, ""},
+		{0, "must_be_positive"},
+		{-1, "must_be_positive"},
+		{math.NaN(), "invalid_format"},
+		{math.Inf(1), "invalid_format"},
+		{math.Inf(-1), "invalid_format"},
+	}
+
+	for _, tt := range tests {
+		verr := domain.NewValidationError()
+		validateRate(verr, "", &domain.ExchangeRate{
+			Base: "USD", Quote: "RUB", Date: date(2025, time.January, 1), Rate: tt.rate,
+		})
+
+		var got string
+		if len(verr.Fields) > 0 {
+			got = verr.Fields[0].Code
+		}
+		if len(verr.Fields) > 1 || got != tt.wantCode {
+			t.Errorf("rate %v: got %+v, want code %q", tt.rate, verr.Fields, tt.wantCode)
+		}
+	}
+}
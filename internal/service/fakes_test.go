@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+// memSubscriptions is an in-memory SubscriptionRepository. Only the
+// methods the tests need are implemented; the others panic through the
+// nil embedded interface.
+type memSubscriptions struct {
+	repo.SubscriptionRepository
+	subs map[uuid.UUID]*domain.Subscription
+	// failCreate, when set, is returned by Create.
+	failCreate error
+}
+
+func newMemSubscriptions(subs ...domain.Subscription) *memSubscriptions {
+	m := &memSubscriptions{subs: make(map[uuid.UUID]*domain.Subscription)}
+	for i := range subs {
+		s := subs[i]
+		if s.Version == 0 {
+			s.Version = 1
+		}
+		m.subs[s.ID] = &s
+	}
+	return m
+}
+
+func (m *memSubscriptions) Create(ctx context.Context, s *domain.Subscription) error {
+	if m.failCreate != nil {
+		return m.failCreate
+	}
+	s.ID, s.Version = uuid.New(), 1
+	stored := *s
+	m.subs[s.ID] = &stored
+	return nil
+}
+
+func (m *memSubscriptions) get(id uuid.UUID) (*domain.Subscription, error) {
+	s, ok := m.subs[id]
+	if !ok || s.DeletedAt != nil {
+		return nil, domain.ErrNotFound
+	}
+	c := *s
+	return &c, nil
+}
+
+func (m *memSubscriptions) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	return m.get(id)
+}
+
+func (m *memSubscriptions) GetForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	return m.get(id)
+}
+
+func (m *memSubscriptions) Update(ctx context.Context, s *domain.Subscription) error {
+	stored, err := m.get(s.ID)
+	if err != nil {
+		return err
+	}
+	if s.Version != 0 && s.Version != stored.Version {
+		return domain.ErrPreconditionFailed
+	}
+	// Like the table, Update leaves the dated changes alone.
+	s.PriceChanges, s.PlanChanges = stored.PriceChanges, stored.PlanChanges
+	s.Pauses, s.Discounts = stored.Pauses, stored.Discounts
+	s.Version = stored.Version + 1
+	c := *s
+	m.subs[s.ID] = &c
+	return nil
+}
+
+func (m *memSubscriptions) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	stored, err := m.get(id)
+	if err != nil {
+		return err
+	}
+	if version != 0 && version != stored.Version {
+		return domain.ErrPreconditionFailed
+	}
+	now := date(2025, 1, 1)
+	m.subs[id].DeletedAt = &now
+	m.subs[id].Version++
+	return nil
+}
+
+func (m *memSubscriptions) GetDeletedForUpdate(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	s, ok := m.subs[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if s.DeletedAt == nil {
+		return nil, domain.ErrConflict
+	}
+	c := *s
+	return &c, nil
+}
+
+func (m *memSubscriptions) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	s, ok := m.subs[id]
+	if !ok {
+		return nil, domain.ErrNotFound
+	}
+	if s.DeletedAt == nil {
+		return nil, domain.ErrConflict
+	}
+	s.DeletedAt = nil
+	s.Version++
+	c := *s
+	return &c, nil
+}
+
+func (m *memSubscriptions) Exists(ctx context.Context, id uuid.UUID) (bool, error) {
+	_, ok := m.subs[id]
+	return ok, nil
+}
+
+func (m *memSubscriptions) List(ctx context.Context, f repo.ListFilter) ([]domain.Subscription, error) {
+	var res []domain.Subscription
+	for _, s := range m.subs {
+		if s.DeletedAt != nil {
+			continue
+		}
+		if f.ServiceID != nil && (s.ServiceID == nil || *s.ServiceID != *f.ServiceID) {
+			continue
+		}
+		res = append(res, *s)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID.String() < res[j].ID.String() })
+	return res, nil
+}
+
+// memEvents records appended events.
+type memEvents struct {
+	events []domain.SubscriptionEvent
+}
+
+func (m *memEvents) Append(ctx context.Context, e *domain.SubscriptionEvent) error {
+	m.events = append(m.events, *e)
+	return nil
+}
+
+func (m *memEvents) List(ctx context.Context, id uuid.UUID) ([]domain.SubscriptionEvent, error) {
+	var res []domain.SubscriptionEvent
+	for _, e := range m.events {
+		if e.SubscriptionID == id {
+			res = append(res, e)
+		}
+	}
+	return res, nil
+}
+
+// memCatalog is an in-memory ServiceRepository for lookups.
+type memCatalog struct {
+	repo.ServiceRepository
+	services []domain.Service
+	plans    []domain.Plan
+}
+
+func (m *memCatalog) GetByID(ctx context.Context, id uuid.UUID) (*domain.Service, error) {
+	for i := range m.services {
+		if m.services[i].ID == id {
+			s := m.services[i]
+			return &s, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memCatalog) FindByName(ctx context.Context, name string) (*domain.Service, error) {
+	key := domain.ServiceKey(name)
+	for i := range m.services {
+		s := m.services[i]
+		for _, n := range append([]string{s.Name}, s.Aliases...) {
+			if domain.ServiceKey(n) == key {
+				return &s, nil
+			}
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+func (m *memCatalog) GetPlan(ctx context.Context, id uuid.UUID) (*domain.Plan, error) {
+	for i := range m.plans {
+		if m.plans[i].ID == id {
+			p := m.plans[i]
+			return &p, nil
+		}
+	}
+	return nil, domain.ErrNotFound
+}
+
+// fakeTx runs fn directly and fails the commit of the outermost
+// transaction with commitErr; nested calls join it like the real one.
+type fakeTx struct {
+	commitErr error
+}
+
+type fakeTxKey struct{}
+
+func (f *fakeTx) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(fakeTxKey{}) != nil {
+		return fn(ctx)
+	}
+	if err := fn(context.WithValue(ctx, fakeTxKey{}, true)); err != nil {
+		return err
+	}
+	return f.commitErr
+}
+
+func newTestService(subs *memSubscriptions, catalog *memCatalog, tx *fakeTx) (*subscriptionService, *memEvents) {
+	events := &memEvents{}
+	if catalog == nil {
+		catalog = &memCatalog{}
+	}
+	if tx == nil {
+		tx = &fakeTx{}
+	}
+	return &subscriptionService{repo: subs, events: events, catalog: catalog, tx: tx}, events
+}
@@ -4,22 +4,138 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 )
 
 type ListFilter struct {
-	UserID      *uuid.UUID
-	ServiceName *string
-	From        *time.Time
-	To          *time.Time
+	UserID *uuid.UUID
+	// ServiceID keeps subscriptions linked to that catalog service. A
+	// ServiceName matched exactly or ignoring case also keeps those linked
+	// to the catalog service known by it.
+	ServiceID        *uuid.UUID
+	ServiceName      *string
+	ServiceNameMatch NameMatch
+	From             *time.Time
+	To               *time.Time
+
+	PriceMin *int
+	PriceMax *int
+	ActiveOn *time.Time
+	Status   domain.Status
+	// TrialEndingWithin keeps trials ending between today and that many
+	// days from now.
+	TrialEndingWithin *int
+
+	IncludeDeleted bool
+	OnlyDeleted    bool
 
+	// Sort defaults to newest first.
+	Sort []SortKey
+
+	// Limit defaults to DefaultPageSize and is capped at MaxPageSize.
 	Limit  int
 	Offset int
+	// Cursor is the NextCursor of a previous page; it cannot be combined
+	// with Offset.
+	Cursor string
 }
 
 type TotalFilter struct {
-	UserID      *uuid.UUID
+	UserID *uuid.UUID
+	// ServiceID and ServiceName match as in ListFilter.
+	ServiceID   *uuid.UUID
 	ServiceName *string
 
-	From time.Time 
+	// From and To are inclusive days. Only TotalModeProrated uses them as
+	// is; the other modes widen them to whole months.
+	From time.Time
 	To   time.Time
+
+	// Currency, when set, converts the total into this currency.
+	Currency string
+	// Mode selects how prices are charged; TotalModeBilled by default.
+	Mode TotalMode
+}
+
+type CatalogFilter struct {
+	Category *string
+	// Search keeps services with a name or alias starting with it,
+	// ignoring case.
+	Search *string
+}
+
+type ExchangeRateFilter struct {
+	Base  *string
+	Quote *string
+	From  *time.Time
+	To    *time.Time
+}
+
+// NameMatch selects how ListFilter.ServiceName is compared.
+type NameMatch string
+
+const (
+	NameMatchExact      NameMatch = "exact"
+	NameMatchIgnoreCase NameMatch = "ignore_case"
+	// NameMatchPrefix matches case-insensitively on the leading characters.
+	NameMatchPrefix NameMatch = "prefix"
+)
+
+func (m NameMatch) Valid() bool {
+	switch m {
+	case NameMatchExact, NameMatchIgnoreCase, NameMatchPrefix:
+		return true
+	default:
+		return false
+	}
+}
+
+type SortField string
+
+const (
+	SortByPrice       SortField = "price"
+	SortByStartDate   SortField = "start_date"
+	SortByEndDate     SortField = "end_date"
+	SortByServiceName SortField = "service_name"
+	SortByCreatedAt   SortField = "created_at"
+	// SortByTrialEndDate sorts subscriptions without a trial last.
+	SortByTrialEndDate SortField = "trial_end_date"
+)
+
+func (f SortField) Valid() bool {
+	switch f {
+	case SortByPrice, SortByStartDate, SortByEndDate, SortByServiceName, SortByCreatedAt, SortByTrialEndDate:
+		return true
+	default:
+		return false
+	}
+}
+
+type SortKey struct {
+	Field SortField
+	Desc  bool
+}
+
+type GroupBy string
+
+const (
+	GroupByServiceName GroupBy = "service_name"
+	GroupByUserID      GroupBy = "user_id"
+	GroupByMonth       GroupBy = "month"
+)
+
+func (g GroupBy) Valid() bool {
+	switch g {
+	case GroupByServiceName, GroupByUserID, GroupByMonth:
+		return true
+	default:
+		return false
+	}
+}
+
+type BreakdownFilter struct {
+	TotalFilter
+
+	GroupBy []GroupBy
 }
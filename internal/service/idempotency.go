@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+// IdempotencyService lets retries of a request under the same key get the
+// original response instead of repeating its effects.
+type IdempotencyService interface {
+	// Begin claims key for a request with the given hash and returns nil,
+	// or returns the completed request to replay. It fails with
+	// ErrIdempotencyKeyReused when the key belongs to a different request
+	// and with ErrIdempotencyInProgress while the original is running. A
+	// request that neither completed nor aborted within its lease, e.g.
+	// because the server crashed, no longer holds the key.
+	Begin(ctx context.Context, key, requestHash string) (*domain.IdempotentRequest, error)
+	// Complete stores the response of a request started with Begin.
+	Complete(ctx context.Context, rec *domain.IdempotentRequest) error
+	// Abort forgets a request started with Begin that produced no
+	// response worth replaying.
+	Abort(ctx context.Context, key string) error
+	// PurgeExpired deletes keys past their TTL.
+	PurgeExpired(ctx context.Context) (int64, error)
+}
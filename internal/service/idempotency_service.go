@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+var (
+	ErrIdempotencyKeyReused  = errors.New("idempotency key was already used for a different request")
+	ErrIdempotencyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type idempotencyService struct {
+	repo  repo.IdempotencyRepository
+	ttl   time.Duration
+	lease time.Duration
+}
+
+// NewIdempotencyService remembers keys for ttl; a request in progress
+// holds its key for lease, after which a retry may take it over.
+func NewIdempotencyService(r repo.IdempotencyRepository, ttl, lease time.Duration) IdempotencyService {
+	return &idempotencyService{repo: r, ttl: ttl, lease: lease}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, key, requestHash string) (*domain.IdempotentRequest, error) {
+	rec, claimed, err := s.repo.Claim(ctx, key, requestHash, s.ttl, s.lease)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case claimed:
+		return nil, nil
+	case rec.RequestHash != requestHash:
+		return nil, ErrIdempotencyKeyReused
+	case !rec.Completed():
+		return nil, ErrIdempotencyInProgress
+	default:
+		return rec, nil
+	}
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, rec *domain.IdempotentRequest) error {
+	return s.repo.Complete(ctx, rec)
+}
+
+func (s *idempotencyService) Abort(ctx context.Context, key string) error {
+	return s.repo.Release(ctx, key)
+}
+
+func (s *idempotencyService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
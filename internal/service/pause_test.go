@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestValidatePause(t *testing.T) {
+	sub := domain.Subscription{
+		StartDate: date(2025, time.January, 10),
+		EndDate:   datePtr(2025, time.December, 31),
+		Pauses: []domain.Pause{
+			{StartDate: date(2025, time.March, 1), EndDate: datePtr(2025, time.March, 31)},
+			{StartDate: date(2025, time.October, 1)},
+		},
+	}
+	pause := func(start time.Time, end *time.Time) domain.Pause {
+		return domain.Pause{StartDate: start, EndDate: end}
+	}
+
+	tests := []struct {
+		name  string
+		pause domain.Pause
+		codes []string
+	}{
+		{"between pauses", pause(date(2025, time.May, 1), datePtr(2025, time.May, 31)), nil},
+		{"on the start date", pause(date(2025, time.January, 10), datePtr(2025, time.January, 31)), nil},
+		{"the day after a pause", pause(date(2025, time.April, 1), datePtr(2025, time.April, 30)), nil},
+		{"ending the day before a pause", pause(date(2025, time.February, 1), datePtr(2025, time.February, 28)), nil},
+		{"on the end date, within an open-ended pause", pause(date(2025, time.December, 31), nil), []string{"overlaps_pause"}},
+		{"no start", pause(time.Time{}, nil), []string{"required"}},
+		{"before the start date", pause(date(2025, time.January, 9), nil), []string{"before_start_date"}},
+		{"after the end date", pause(date(2026, time.January, 1), nil), []string{"after_end_date"}},
+		{"ending before it starts", pause(date(2025, time.May, 10), datePtr(2025, time.May, 9)), []string{"before_start_date"}},
+		{"both wrong", pause(date(2025, time.January, 1), datePtr(2024, time.December, 31)), []string{"before_start_date", "before_start_date"}},
+		{"inside a pause", pause(date(2025, time.March, 10), datePtr(2025, time.March, 20)), []string{"overlaps_pause"}},
+		{"into a pause", pause(date(2025, time.February, 20), datePtr(2025, time.March, 1)), []string{"overlaps_pause"}},
+		{"around a pause", pause(date(2025, time.February, 1), datePtr(2025, time.April, 30)), []string{"overlaps_pause"}},
+		{"open-ended into a pause", pause(date(2025, time.June, 1), nil), []string{"overlaps_pause"}},
+		{"after an open-ended pause", pause(date(2025, time.November, 1), datePtr(2025, time.November, 30)), []string{"overlaps_pause"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCodes(t, validatePause(&sub, tt.pause), tt.codes...)
+		})
+	}
+}
+
+func TestPauseToResume(t *testing.T) {
+	bounded := domain.Pause{StartDate: date(2025, time.March, 1), EndDate: datePtr(2025, time.March, 31)}
+	open := domain.Pause{StartDate: date(2025, time.October, 1)}
+	sub := domain.Subscription{StartDate: date(2025, time.January, 1), Pauses: []domain.Pause{bounded, open}}
+
+	tests := []struct {
+		name   string
+		on     time.Time
+		want   domain.Pause
+		paused bool
+	}{
+		{"first day of a pause", date(2025, time.March, 1), bounded, true},
+		{"within a pause", date(2025, time.March, 15), bounded, true},
+		{"last day of a pause", date(2025, time.March, 31), bounded, true},
+		{"within an open-ended pause", date(2026, time.June, 1), open, true},
+		{"before any pause", date(2025, time.February, 28), domain.Pause{}, false},
+		{"after a pause ended", date(2025, time.April, 1), domain.Pause{}, false},
+		{"the day before an open-ended pause", date(2025, time.September, 30), domain.Pause{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pauseToResume(&sub, tt.on)
+			if !tt.paused {
+				if !errors.Is(err, domain.ErrConflict) {
+					t.Fatalf("got %v, want %v", err, domain.ErrConflict)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.StartDate.Equal(tt.want.StartDate) {
+				t.Errorf("got the pause from %s, want the one from %s",
+					got.StartDate.Format(time.DateOnly), tt.want.StartDate.Format(time.DateOnly))
+			}
+		})
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	sub := domain.Subscription{
+		StartDate:    date(2025, time.January, 10),
+		Pauses:       []domain.Pause{{StartDate: date(2025, time.March, 1), EndDate: datePtr(2025, time.March, 31)}},
+		PriceChanges: []domain.PriceChange{{EffectiveFrom: date(2025, time.May, 1), Price: 200}},
+		PlanChanges:  []domain.PlanChange{{EffectiveFrom: date(2025, time.July, 1), Price: 300}},
+	}
+	with := func(start time.Time, end *time.Time) domain.Subscription {
+		s := sub
+		s.StartDate, s.EndDate = start, end
+		return s
+	}
+
+	tests := []struct {
+		name  string
+		sub   domain.Subscription
+		codes []string
+	}{
+		{"unchanged", sub, nil},
+		{"start on the first pause", with(date(2025, time.March, 1), nil), nil},
+		{"end on the last change", with(sub.StartDate, datePtr(2025, time.July, 1)), nil},
+		{"start past a pause", with(date(2025, time.March, 2), nil), []string{"after_scheduled_change"}},
+		{"start on a price change", with(date(2025, time.May, 1), nil), []string{"after_scheduled_change", "after_scheduled_change"}},
+		{"start past everything", with(date(2025, time.August, 1), nil), []string{"after_scheduled_change", "after_scheduled_change", "after_scheduled_change"}},
+		{"end before a plan change", with(sub.StartDate, datePtr(2025, time.June, 30)), []string{"before_scheduled_change"}},
+		{"end before a pause", with(sub.StartDate, datePtr(2025, time.February, 28)), []string{"before_scheduled_change", "before_scheduled_change", "before_scheduled_change"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertCodes(t, validateSchedule(&tt.sub), tt.codes...)
+		})
+	}
+}
+
+// TestEditsKeepSchedule checks that PUT and PATCH cannot move the dates of
+// a subscription past its pauses, price changes or plan changes.
+func TestEditsKeepSchedule(t *testing.T) {
+	stored := domain.Subscription{
+		ID: uuid.New(), UserID: uuid.New(), ServiceName: "Netflix", Price: 100, Currency: "RUB",
+		Billing: domain.MonthlyBilling, StartDate: date(2025, time.January, 10),
+		Pauses:       []domain.Pause{{StartDate: date(2025, time.March, 1), EndDate: datePtr(2025, time.March, 31)}},
+		PriceChanges: []domain.PriceChange{{EffectiveFrom: date(2025, time.May, 1), Price: 200}},
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		end   *time.Time
+		codes []string
+	}{
+		{"earlier start", date(2025, time.January, 1), nil, nil},
+		{"start past a pause", date(2025, time.April, 1), nil, []string{"after_scheduled_change"}},
+		{"end before a price change", stored.StartDate, datePtr(2025, time.April, 30), []string{"before_scheduled_change"}},
+	}
+
+	for _, tt := range tests {
+		edit := func(sub *domain.Subscription) {
+			sub.StartDate, sub.EndDate = tt.start, tt.end
+		}
+
+		t.Run("PUT/"+tt.name, func(t *testing.T) {
+			s, events := newTestService(newMemSubscriptions(stored), &memCatalog{}, &fakeTx{})
+			sub := stored
+			sub.Pauses, sub.PriceChanges, sub.Version = nil, nil, 1
+			edit(&sub)
+
+			err := s.Update(context.Background(), &sub)
+			checkScheduleEdit(t, err, events, tt.codes)
+		})
+
+		t.Run("PATCH/"+tt.name, func(t *testing.T) {
+			s, events := newTestService(newMemSubscriptions(stored), &memCatalog{}, &fakeTx{})
+
+			_, err := s.Patch(context.Background(), stored.ID, 1, func(sub *domain.Subscription) error {
+				edit(sub)
+				return nil
+			})
+			checkScheduleEdit(t, err, events, tt.codes)
+		})
+	}
+}
+
+func checkScheduleEdit(t *testing.T, err error, events *memEvents, codes []string) {
+	t.Helper()
+
+	var verr *domain.ValidationError
+	if err != nil && !errors.As(err, &verr) {
+		t.Fatalf("got %v, want a validation error", err)
+	}
+	assertCodes(t, verr, codes...)
+	if want := len(codes) == 0; (len(events.events) == 1) != want {
+		t.Errorf("got %d events, want one only when the edit is valid", len(events.events))
+	}
+}
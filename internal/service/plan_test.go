@@ -0,0 +1,70 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestValidatePlanChange(t *testing.T) {
+	serviceID := uuid.New()
+	sub := domain.Subscription{
+		ServiceID: &serviceID,
+		Currency:  "RUB",
+		StartDate: date(2025, time.January, 10),
+		EndDate:   datePtr(2025, time.December, 31),
+	}
+	plan := domain.Plan{ID: uuid.New(), ServiceID: serviceID, Currency: "RUB"}
+	otherService, otherCurrency := plan, plan
+	otherService.ServiceID = uuid.New()
+	otherCurrency.Currency = "USD"
+
+	tests := []struct {
+		name  string
+		sub   domain.Subscription
+		plan  domain.Plan
+		from  time.Time
+		codes []string
+	}{
+		{"valid", sub, plan, date(2025, time.March, 1), nil},
+		{"plan of another service", sub, otherService, date(2025, time.March, 1), []string{"other_service"}},
+		{"unlinked subscription", domain.Subscription{Currency: "RUB", StartDate: sub.StartDate}, plan, date(2025, time.March, 1), []string{"other_service"}},
+		{"other currency", sub, otherCurrency, date(2025, time.March, 1), []string{"currency_mismatch"}},
+		{"no date", sub, plan, time.Time{}, []string{"required"}},
+		{"on the start date", sub, plan, date(2025, time.January, 10), []string{"not_after_start_date"}},
+		{"on the end date", sub, plan, date(2025, time.December, 31), nil},
+		{"after the end date", sub, plan, date(2026, time.January, 1), []string{"after_end_date"}},
+		{"both wrong", sub, otherCurrency, date(2024, time.January, 1), []string{"currency_mismatch", "not_after_start_date"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verr := validatePlanChange(&tt.sub, &tt.plan, tt.from)
+			assertCodes(t, verr, tt.codes...)
+		})
+	}
+}
+
+// assertCodes checks that verr holds exactly the given error codes, in
+// order.
+func assertCodes(t *testing.T, verr *domain.ValidationError, codes ...string) {
+	t.Helper()
+
+	var got []string
+	if verr != nil {
+		for _, f := range verr.Fields {
+			got = append(got, f.Code)
+		}
+	}
+	if len(got) != len(codes) {
+		t.Fatalf("got codes %v, want %v", got, codes)
+	}
+	for i := range codes {
+		if got[i] != codes[i] {
+			t.Fatalf("got codes %v, want %v", got, codes)
+		}
+	}
+}
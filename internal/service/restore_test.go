@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+)
+
+func TestRestoreRecordsDeletedState(t *testing.T) {
+	ctx := context.Background()
+	stored := domain.Subscription{
+		ID: uuid.New(), UserID: uuid.New(), ServiceName: "Netflix", Price: 100, Currency: "RUB",
+		Billing: domain.MonthlyBilling, StartDate: date(2025, time.January, 10),
+	}
+	s, events := newTestService(newMemSubscriptions(stored), &memCatalog{}, &fakeTx{})
+
+	if _, err := s.Restore(ctx, stored.ID); !errors.Is(err, domain.ErrConflict) {
+		t.Fatalf("restoring a subscription not deleted: got %v, want %v", err, domain.ErrConflict)
+	}
+	if _, err := s.Restore(ctx, uuid.New()); !errors.Is(err, domain.ErrNotFound) {
+		t.Fatalf("restoring an unknown subscription: got %v, want %v", err, domain.ErrNotFound)
+	}
+	if len(events.events) != 0 {
+		t.Fatalf("got %d events for failed restores, want none", len(events.events))
+	}
+
+	if err := s.Delete(ctx, stored.ID, 0); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := s.Restore(ctx, stored.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events.events) != 2 {
+		t.Fatalf("got %d events, want a delete and a restore", len(events.events))
+	}
+	e := events.events[1]
+	if e.Type != domain.EventRestored || e.Before == nil || e.After == nil {
+		t.Fatalf("got a %s event with before %v and after %v, want a restore with both", e.Type, e.Before, e.After)
+	}
+	if e.Before.DeletedAt == nil || e.Before.Version != restored.Version-1 {
+		t.Errorf("before: got deleted at %v in version %d, want the deleted version %d",
+			e.Before.DeletedAt, e.Before.Version, restored.Version-1)
+	}
+	if e.After.DeletedAt != nil || e.After.Version != restored.Version {
+		t.Errorf("after: got deleted at %v in version %d, want restored in version %d",
+			e.After.DeletedAt, e.After.Version, restored.Version)
+	}
+}
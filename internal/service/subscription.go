@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 	"github.com/google/uuid"
@@ -11,8 +12,103 @@ type SubscriptionService interface {
 	Create(ctx context.Context, s *domain.Subscription) error
 	GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
 	Update(ctx context.Context, s *domain.Subscription) error
-	Delete(ctx context.Context, id uuid.UUID) error
-	List(ctx context.Context, f ListFilter) ([]domain.Subscription, error)
+	// Patch applies fn to the stored subscription and saves the validated
+	// result atomically. A non-zero version must match the stored one.
+	Patch(ctx context.Context, id uuid.UUID, version int, fn func(*domain.Subscription) error) (*domain.Subscription, error)
+	// Delete moves the subscription to the trash, from which Restore
+	// brings it back until PurgeDeleted removes it.
+	Delete(ctx context.Context, id uuid.UUID, version int) error
+	Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error)
+	// ChangePrice makes pc.Price the price from pc.EffectiveFrom on, which
+	// may be in the past or the future; a change on the same day is
+	// replaced. Totals charge each billing date the price in effect on it.
+	ChangePrice(ctx context.Context, id uuid.UUID, version int, pc domain.PriceChange) (*domain.Subscription, error)
+	// CancelPriceChange removes the price change on effectiveFrom.
+	CancelPriceChange(ctx context.Context, id uuid.UUID, version int, effectiveFrom time.Time) (*domain.Subscription, error)
+	// ChangePlan moves the subscription to planID, a plan of its service,
+	// from effectiveFrom on: a new billing cycle at the price and interval
+	// of the plan starts that day, cutting the current one short. A change
+	// on the same day is replaced. CancelPlanChange removes one.
+	ChangePlan(ctx context.Context, id uuid.UUID, version int, planID uuid.UUID, effectiveFrom time.Time) (*domain.Subscription, error)
+	CancelPlanChange(ctx context.Context, id uuid.UUID, version int, effectiveFrom time.Time) (*domain.Subscription, error)
+	// Pause stops billing for p, which must not overlap another pause;
+	// billing dates keep their anchor and those inside p are skipped.
+	Pause(ctx context.Context, id uuid.UUID, version int, p domain.Pause) (*domain.Subscription, error)
+	// Resume ends the pause covering on, so that billing resumes that day;
+	// resuming on the first day of a pause cancels it. It fails with
+	// domain.ErrConflict when no pause covers on.
+	Resume(ctx context.Context, id uuid.UUID, version int, on time.Time) (*domain.Subscription, error)
+	// AddDiscount attaches d, which covers either a date range or the
+	// first d.Periods billing periods; discounts covering the same charge
+	// add up to at most its price. RemoveDiscount detaches one by ID.
+	AddDiscount(ctx context.Context, id uuid.UUID, version int, d domain.Discount) (*domain.Subscription, error)
+	RemoveDiscount(ctx context.Context, id uuid.UUID, version int, discountID int64) (*domain.Subscription, error)
+	// History lists the recorded changes of a subscription, oldest first,
+	// also once it is deleted or purged. A stored subscription without
+	// any has an empty history.
+	History(ctx context.Context, id uuid.UUID) ([]domain.SubscriptionEvent, error)
+	// PurgeDeleted removes subscriptions deleted more than retention ago.
+	PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error)
+	List(ctx context.Context, f ListFilter) (ListResult, error)
+	// TrialsEnding lists the trials that end between today and within
+	// days from now and then convert into paid subscriptions, soonest
+	// first.
+	TrialsEnding(ctx context.Context, userID *uuid.UUID, within int) ([]domain.Subscription, error)
+	// Batch runs ops as one transaction in BatchAtomic mode and one by one
+	// in BatchBestEffort mode, reporting the outcome of each. An atomic
+	// batch that fails without any operation failing, e.g. on commit,
+	// returns that error instead.
+	Batch(ctx context.Context, mode BatchMode, ops []BatchOperation) ([]BatchResult, error)
 
-	Total(ctx context.Context, f TotalFilter) (int, error)
+	Total(ctx context.Context, f TotalFilter) (TotalResult, error)
+	Breakdown(ctx context.Context, f BreakdownFilter) (BreakdownResult, error)
+}
+
+type ListResult struct {
+	Items []domain.Subscription
+	// NextCursor is empty on the last page.
+	NextCursor string
+
+	// TotalCount is the number of matches across all pages.
+	TotalCount int
+	// Limit is the page size actually applied.
+	Limit  int
+	Offset int
+}
+
+// Money is an amount charged: Amount is net, Gross less Discount.
+type Money struct {
+	Amount   int
+	Gross    int
+	Discount int
+	Currency string
+}
+
+type TotalResult struct {
+	// Total is expressed in Currency, net of discounts: Gross less
+	// Discount. Without a requested target currency it is only filled when
+	// every matching subscription shares one currency.
+	Total    int
+	Gross    int
+	Discount int
+	Currency string
+
+	// Subtotals holds the unconverted sum per currency.
+	Subtotals []Money
+}
+
+// BreakdownGroup is the total of one combination of grouped dimensions;
+// dimensions that were not grouped on are nil.
+type BreakdownGroup struct {
+	ServiceName *string
+	UserID      *uuid.UUID
+	Month       *time.Time
+
+	TotalResult
+}
+
+type BreakdownResult struct {
+	GroupBy    []GroupBy
+	Groups     []BreakdownGroup
+	GrandTotal TotalResult
 }
@@ -3,48 +3,200 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
 	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+	"github.com/RomaNano/subscriptions-aggregator/internal/reqctx"
+)
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
 )
 
 var (
 	ErrInvalidPeriod = errors.New("invalid period")
-	ErrInvalidData   = errors.New("invalid subscription data")
+	ErrInvalidCursor = repo.ErrInvalidCursor
 )
 
 type subscriptionService struct {
-	repo repo.SubscriptionRepository
+	repo      repo.SubscriptionRepository
+	events    repo.SubscriptionEventRepository
+	catalog   repo.ServiceRepository
+	tx        repo.TxManager
+	converter CurrencyConverter
 }
 
-func NewSubscriptionService(r repo.SubscriptionRepository) SubscriptionService {
-	return &subscriptionService{repo: r}
+func NewSubscriptionService(
+	r repo.SubscriptionRepository,
+	events repo.SubscriptionEventRepository,
+	catalog repo.ServiceRepository,
+	tx repo.TxManager,
+	converter CurrencyConverter,
+) SubscriptionService {
+	return &subscriptionService{repo: r, events: events, catalog: catalog, tx: tx, converter: converter}
 }
 
-func validateSubscription(s *domain.Subscription) error {
-	if s.UserID == uuid.Nil {
-		return ErrInvalidData
+// resolveService links sub to the catalog service given by its ServiceID,
+// that of its plan or, failing those, the one known by its ServiceName, and
+// then names it canonically and fills in the fields sub leaves unset from
+// its plan, or else the defaults of the service. Services unknown to the
+// catalog keep their free-text name.
+func (s *subscriptionService) resolveService(ctx context.Context, sub *domain.Subscription) error {
+	var (
+		plan *domain.Plan
+		svc  *domain.Service
+		err  error
+	)
+	if sub.PlanID != nil {
+		if plan, err = s.plan(ctx, *sub.PlanID); err != nil {
+			return err
+		}
+		if sub.ServiceID == nil {
+			sub.ServiceID = &plan.ServiceID
+		}
 	}
-	if s.ServiceName == "" {
-		return ErrInvalidData
+
+	if sub.ServiceID != nil {
+		svc, err = s.catalog.GetByID(ctx, *sub.ServiceID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return domain.NewValidationError(domain.FieldError{
+				Field: "service_id", Code: "unknown_service", Message: "is not in the service catalog",
+			})
+		}
+	} else {
+		svc, err = s.catalog.FindByName(ctx, sub.ServiceName)
+		if errors.Is(err, domain.ErrNotFound) {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if plan != nil && plan.ServiceID != svc.ID {
+		return domain.NewValidationError(domain.FieldError{
+			Field: "plan_id", Code: "other_service", Message: "must be a plan of the service",
+		})
+	}
+
+	sub.ServiceID = &svc.ID
+	sub.ServiceName = svc.Name
+	if plan != nil {
+		if sub.Price == 0 {
+			sub.Price = plan.Price
+		}
+		if sub.Currency == "" {
+			sub.Currency = plan.Currency
+		}
+		if sub.Billing.Unit == "" && sub.Billing.Count == 0 {
+			sub.Billing = plan.Billing
+		}
+	}
+	if sub.Price == 0 && svc.DefaultPrice != nil {
+		sub.Price = *svc.DefaultPrice
+	}
+	if sub.Currency == "" {
+		sub.Currency = svc.DefaultCurrency
+	}
+	if sub.Billing.Unit == "" && sub.Billing.Count == 0 {
+		sub.Billing = svc.DefaultBilling
+	}
+
+	return nil
+}
+
+// plan looks up a plan referred to by the client.
+func (s *subscriptionService) plan(ctx context.Context, id uuid.UUID) (*domain.Plan, error) {
+	plan, err := s.catalog.GetPlan(ctx, id)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, domain.NewValidationError(domain.FieldError{
+			Field: "plan_id", Code: "unknown_plan", Message: "is not a plan in the service catalog",
+		})
+	}
+	return plan, err
+}
+
+// linkedService returns the catalog service an exact service name filter
+// also matches by link, if any.
+func (s *subscriptionService) linkedService(ctx context.Context, name *string) (*uuid.UUID, error) {
+	if name == nil {
+		return nil, nil
+	}
+
+	svc, err := s.catalog.FindByName(ctx, *name)
+	if errors.Is(err, domain.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &svc.ID, nil
+}
+
+// validateSubscription checks the fields shared by create and update.
+func validateSubscription(s *domain.Subscription) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	s.Currency = domain.NormalizeCurrency(s.Currency)
+	if s.Currency == "" {
+		s.Currency = domain.DefaultCurrency
+	}
+	if s.Billing.Unit == "" {
+		s.Billing.Unit = domain.MonthlyBilling.Unit
+	}
+	if s.Billing.Count == 0 {
+		s.Billing.Count = domain.MonthlyBilling.Count
+	}
+
+	if strings.TrimSpace(s.ServiceName) == "" {
+		verr.Add("service_name", "required", "is required")
 	}
 	if s.Price <= 0 {
-		return ErrInvalidData
+		verr.Add("price", "must_be_positive", "must be greater than 0")
+	}
+	validateCurrency(verr, "currency", s.Currency)
+	if !s.Billing.Unit.Valid() {
+		verr.Add("billing_unit", "invalid_billing_unit", "must be one of week, month, year")
+	}
+	if s.Billing.Count < 0 {
+		verr.Add("billing_count", "must_be_positive", "must be greater than 0")
+	}
+	if s.StartDate.IsZero() {
+		verr.Add("start_date", "required", "is required")
 	}
 	if s.EndDate != nil && s.EndDate.Before(s.StartDate) {
-		return ErrInvalidData
+		verr.Add("end_date", "before_start_date", "must not be before start_date")
 	}
-	return nil
+	if s.TrialEndDate != nil && s.TrialEndDate.Before(s.StartDate) {
+		verr.Add("trial_end_date", "before_start_date", "must not be before start_date")
+	}
+
+	return verr
+}
+
+// validateNew additionally checks the fields only set on creation.
+func validateNew(s *domain.Subscription) error {
+	verr := validateSubscription(s)
+	if s.UserID == uuid.Nil {
+		verr.Add("user_id", "required", "is required")
+	}
+	return verr.Err()
 }
 
 func (s *subscriptionService) Create(ctx context.Context, sub *domain.Subscription) error {
-	if err := validateSubscription(sub); err != nil {
+	if err := s.resolveService(ctx, sub); err != nil {
+		return err
+	}
+	if err := validateNew(sub); err != nil {
 		return err
 	}
-	return s.repo.Create(ctx, sub)
+	return s.create(ctx, sub)
 }
 
 func (s *subscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
@@ -52,90 +204,583 @@ func (s *subscriptionService) GetByID(ctx context.Context, id uuid.UUID) (*domai
 }
 
 func (s *subscriptionService) Update(ctx context.Context, sub *domain.Subscription) error {
-	if err := validateSubscription(sub); err != nil {
+	if err := s.resolveService(ctx, sub); err != nil {
 		return err
 	}
-	return s.repo.Update(ctx, sub)
+	if err := validateSubscription(sub).Err(); err != nil {
+		return err
+	}
+	return s.update(ctx, sub)
 }
 
-func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID) error {
-	return s.repo.Delete(ctx, id)
-}
+func (s *subscriptionService) Patch(ctx context.Context, id uuid.UUID, version int, fn func(*domain.Subscription) error) (*domain.Subscription, error) {
+	var sub *domain.Subscription
 
-func (s *subscriptionService) List(ctx context.Context, f ListFilter) ([]domain.Subscription, error) {
-	rf := repo.ListFilter{
-		UserID:      f.UserID,
-		ServiceName: f.ServiceName,
-		From:        f.From,
-		To:          f.To,
-		Limit:       f.Limit,
-		Offset:     f.Offset,
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = s.repo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if version != 0 && sub.Version != version {
+			return domain.ErrPreconditionFailed
+		}
+		before := *sub
+
+		if err := fn(sub); err != nil {
+			return err
+		}
+		sub.ID = id
+		// A renamed subscription is linked by its new name, and keeps its
+		// plan only when that is still the same service.
+		renamed := sub.ServiceName != before.ServiceName && sameID(sub.ServiceID, before.ServiceID)
+		if renamed {
+			sub.ServiceID = nil
+			if sameID(sub.PlanID, before.PlanID) {
+				sub.PlanID = nil
+			}
+		}
+		if err := s.resolveService(ctx, sub); err != nil {
+			return err
+		}
+		if renamed && sub.PlanID == nil && sameID(sub.ServiceID, before.ServiceID) {
+			sub.PlanID = before.PlanID
+		}
+		if err := validateSubscription(sub).Err(); err != nil {
+			return err
+		}
+		if err := validateSchedule(sub).Err(); err != nil {
+			return err
+		}
+
+		// The row is locked, so the version read above is still current.
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		return s.record(ctx, domain.EventUpdated, &before, sub)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return s.repo.List(ctx, rf)
+
+	return sub, nil
 }
 
-func firstOfMonth(t time.Time) time.Time {
-	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+func sameID(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
 }
 
-func monthsBetweenInclusive(a, b time.Time) int {
-	ay, am := a.Year(), int(a.Month())
-	by, bm := b.Year(), int(b.Month())
-	return (by-ay)*12 + (bm-am) + 1
+func (s *subscriptionService) Delete(ctx context.Context, id uuid.UUID, version int) error {
+	return s.delete(ctx, id, version)
 }
 
-func (s *subscriptionService) Total(ctx context.Context, f TotalFilter) (int, error) {
-	if f.To.Before(f.From) {
-		return 0, ErrInvalidPeriod
+func (s *subscriptionService) Restore(ctx context.Context, id uuid.UUID) (*domain.Subscription, error) {
+	var sub *domain.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetDeletedForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if sub, err = s.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		return s.record(ctx, domain.EventRestored, before, sub)
+	})
+	if err != nil {
+		return nil, err
 	}
 
+	return sub, nil
+}
 
-	rf := repo.ListFilter{
-		UserID:      f.UserID,
-		ServiceName: f.ServiceName,
-		From:        &f.To,   
-		To:          &f.From, 
-		Limit:       0,
-		Offset:     0,
+func (s *subscriptionService) History(ctx context.Context, id uuid.UUID) ([]domain.SubscriptionEvent, error) {
+	events, err := s.events.List(ctx, id)
+	if err != nil || len(events) > 0 {
+		return events, err
 	}
 
-	subs, err := s.repo.List(ctx, rf)
+	// History outlives purged subscriptions, so only a subscription that
+	// was never recorded can be unknown; one without events, e.g. created
+	// before history was kept, has an empty history.
+	exists, err := s.repo.Exists(ctx, id)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	if !exists {
+		return nil, domain.ErrNotFound
+	}
+	return []domain.SubscriptionEvent{}, nil
+}
 
-	from := firstOfMonth(f.From)
-	to := firstOfMonth(f.To)
+func (s *subscriptionService) ChangePrice(ctx context.Context, id uuid.UUID, version int, pc domain.PriceChange) (*domain.Subscription, error) {
+	pc.EffectiveFrom = truncateDay(pc.EffectiveFrom)
 
-	total := 0
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		if err := validatePriceChange(sub, pc).Err(); err != nil {
+			return err
+		}
+		return s.repo.SetPriceChange(ctx, id, pc)
+	})
+}
 
-	for _, sub := range subs {
-		subStart := firstOfMonth(sub.StartDate)
+func (s *subscriptionService) CancelPriceChange(ctx context.Context, id uuid.UUID, version int, effectiveFrom time.Time) (*domain.Subscription, error) {
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		err := s.repo.DeletePriceChange(ctx, id, truncateDay(effectiveFrom))
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: no price change on %s", domain.ErrNotFound, effectiveFrom.Format(time.DateOnly))
+		}
+		return err
+	})
+}
 
-		subEnd := to
-		if sub.EndDate != nil {
-			se := firstOfMonth(*sub.EndDate)
-			if se.Before(subEnd) {
-				subEnd = se
-			}
+func (s *subscriptionService) ChangePlan(ctx context.Context, id uuid.UUID, version int, planID uuid.UUID, effectiveFrom time.Time) (*domain.Subscription, error) {
+	effectiveFrom = truncateDay(effectiveFrom)
+
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		plan, err := s.plan(ctx, planID)
+		if err != nil {
+			return err
+		}
+		if err := validatePlanChange(sub, plan, effectiveFrom).Err(); err != nil {
+			return err
+		}
+		return s.repo.SetPlanChange(ctx, id, domain.PlanChange{
+			EffectiveFrom: effectiveFrom,
+			PlanID:        &plan.ID,
+			PlanName:      plan.Name,
+			Price:         plan.Price,
+			Billing:       plan.Billing,
+		})
+	})
+}
+
+func (s *subscriptionService) CancelPlanChange(ctx context.Context, id uuid.UUID, version int, effectiveFrom time.Time) (*domain.Subscription, error) {
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		err := s.repo.DeletePlanChange(ctx, id, truncateDay(effectiveFrom))
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: no plan change on %s", domain.ErrNotFound, effectiveFrom.Format(time.DateOnly))
+		}
+		return err
+	})
+}
+
+// modify runs fn, which changes what the subscription keeps in child
+// tables, on the locked subscription and bumps its version so that the
+// change invalidates ETags like any other.
+func (s *subscriptionService) modify(
+	ctx context.Context,
+	id uuid.UUID,
+	version int,
+	fn func(ctx context.Context, sub *domain.Subscription) error,
+) (*domain.Subscription, error) {
+	var sub *domain.Subscription
+
+	err := s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if sub, err = s.repo.GetForUpdate(ctx, id); err != nil {
+			return err
+		}
+		if version != 0 && sub.Version != version {
+			return domain.ErrPreconditionFailed
+		}
+		before := *sub
+
+		if err := fn(ctx, sub); err != nil {
+			return err
+		}
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		return s.record(ctx, domain.EventUpdated, &before, sub)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return sub, nil
+}
+
+func (s *subscriptionService) Pause(ctx context.Context, id uuid.UUID, version int, p domain.Pause) (*domain.Subscription, error) {
+	p.StartDate = truncateDay(p.StartDate)
+	if p.EndDate != nil {
+		end := truncateDay(*p.EndDate)
+		p.EndDate = &end
+	}
+
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		if err := validatePause(sub, p).Err(); err != nil {
+			return err
+		}
+		return s.repo.AddPause(ctx, id, p)
+	})
+}
+
+func (s *subscriptionService) Resume(ctx context.Context, id uuid.UUID, version int, on time.Time) (*domain.Subscription, error) {
+	on = truncateDay(on)
+
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		p, err := pauseToResume(sub, on)
+		if err != nil {
+			return err
 		}
+		if p.StartDate.Equal(on) {
+			return s.repo.DeletePause(ctx, id, p.StartDate)
+		}
+		return s.repo.EndPause(ctx, id, p.StartDate, on.AddDate(0, 0, -1))
+	})
+}
+
+// pauseToResume returns the pause of sub that resuming on day cuts short,
+// or drops when it starts that day, and fails with domain.ErrConflict when
+// sub is not paused on day.
+func pauseToResume(sub *domain.Subscription, on time.Time) (domain.Pause, error) {
+	for _, p := range sub.Pauses {
+		if p.Covers(on) {
+			return p, nil
+		}
+	}
+	return domain.Pause{}, fmt.Errorf("%w: subscription is not paused on %s", domain.ErrConflict, on.Format(time.DateOnly))
+}
+
+func (s *subscriptionService) AddDiscount(ctx context.Context, id uuid.UUID, version int, d domain.Discount) (*domain.Subscription, error) {
+	for _, t := range []**time.Time{&d.StartDate, &d.EndDate} {
+		if *t != nil {
+			day := truncateDay(**t)
+			*t = &day
+		}
+	}
+
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		if err := validateDiscount(d).Err(); err != nil {
+			return err
+		}
+		return s.repo.AddDiscount(ctx, id, &d)
+	})
+}
+
+func (s *subscriptionService) RemoveDiscount(ctx context.Context, id uuid.UUID, version int, discountID int64) (*domain.Subscription, error) {
+	return s.modify(ctx, id, version, func(ctx context.Context, sub *domain.Subscription) error {
+		err := s.repo.DeleteDiscount(ctx, id, discountID)
+		if errors.Is(err, domain.ErrNotFound) {
+			return fmt.Errorf("%w: no discount %d", domain.ErrNotFound, discountID)
+		}
+		return err
+	})
+}
+
+func validateDiscount(d domain.Discount) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	switch {
+	case !d.Kind.Valid():
+		verr.Add("kind", "invalid_kind", "must be one of percent, fixed")
+	case d.Value <= 0:
+		verr.Add("value", "must_be_positive", "must be greater than 0")
+	case d.Kind == domain.DiscountPercent && d.Value > 100:
+		verr.Add("value", "out_of_range", "must not exceed 100 percent")
+	}
+
+	switch {
+	case d.Periods < 0:
+		verr.Add("periods", "must_be_positive", "must be greater than 0")
+	case d.Periods > 0 && (d.StartDate != nil || d.EndDate != nil):
+		verr.Add("periods", "conflicting_window", "cannot be combined with start_date and end_date")
+	case d.Periods == 0 && d.StartDate == nil:
+		verr.Add("start_date", "required", "is required unless periods is given")
+	case d.EndDate != nil && d.EndDate.Before(*d.StartDate):
+		verr.Add("end_date", "before_start_date", "must not be before start_date")
+	}
+
+	return verr
+}
+
+func validatePause(sub *domain.Subscription, p domain.Pause) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	switch {
+	case p.StartDate.IsZero():
+		verr.Add("start_date", "required", "is required")
+	case p.StartDate.Before(truncateDay(sub.StartDate)):
+		verr.Add("start_date", "before_start_date", "must not be before the start_date of the subscription")
+	case sub.EndDate != nil && p.StartDate.After(truncateDay(*sub.EndDate)):
+		verr.Add("start_date", "after_end_date", "must not be after the end_date of the subscription")
+	}
+	if p.EndDate != nil && p.EndDate.Before(p.StartDate) {
+		verr.Add("end_date", "before_start_date", "must not be before start_date")
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+
+	for _, other := range sub.Pauses {
+		if pausesOverlap(p, other) {
+			verr.Add("start_date", "overlaps_pause", "overlaps the pause from "+other.StartDate.Format(time.DateOnly))
+			break
+		}
+	}
+
+	return verr
+}
+
+func pausesOverlap(a, b domain.Pause) bool {
+	return (a.EndDate == nil || !a.EndDate.Before(b.StartDate)) &&
+		(b.EndDate == nil || !b.EndDate.Before(a.StartDate))
+}
+
+func validatePriceChange(sub *domain.Subscription, pc domain.PriceChange) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	if pc.Price <= 0 {
+		verr.Add("price", "must_be_positive", "must be greater than 0")
+	}
+	switch {
+	case pc.EffectiveFrom.IsZero():
+		verr.Add("effective_from", "required", "is required")
+	case !pc.EffectiveFrom.After(truncateDay(sub.StartDate)):
+		verr.Add("effective_from", "not_after_start_date", "must be after start_date; update price to change the initial price")
+	case sub.EndDate != nil && pc.EffectiveFrom.After(truncateDay(*sub.EndDate)):
+		verr.Add("effective_from", "after_end_date", "must not be after end_date")
+	}
+
+	return verr
+}
+
+func validatePlanChange(sub *domain.Subscription, plan *domain.Plan, effectiveFrom time.Time) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	switch {
+	case sub.ServiceID == nil || *sub.ServiceID != plan.ServiceID:
+		verr.Add("plan_id", "other_service", "must be a plan of the service of the subscription")
+	case plan.Currency != sub.Currency:
+		verr.Add("plan_id", "currency_mismatch", "must be priced in the currency of the subscription")
+	}
+
+	switch {
+	case effectiveFrom.IsZero():
+		verr.Add("effective_from", "required", "is required")
+	case !effectiveFrom.After(truncateDay(sub.StartDate)):
+		verr.Add("effective_from", "not_after_start_date", "must be after start_date; update plan_id to change the initial plan")
+	case sub.EndDate != nil && effectiveFrom.After(truncateDay(*sub.EndDate)):
+		verr.Add("effective_from", "after_end_date", "must not be after end_date")
+	}
+
+	return verr
+}
+
+// validateSchedule checks that the pauses, price changes and plan changes
+// of sub still lie within its dates once those are edited, as Pause,
+// ChangePrice and ChangePlan required when they were made.
+func validateSchedule(sub *domain.Subscription) *domain.ValidationError {
+	verr := domain.NewValidationError()
+
+	start := truncateDay(sub.StartDate)
+	var end *time.Time
+	if sub.EndDate != nil {
+		day := truncateDay(*sub.EndDate)
+		end = &day
+	}
+
+	checkEnd := func(what string, day time.Time) {
+		if end != nil && day.After(*end) {
+			verr.Add("end_date", "before_scheduled_change", "must not be before the "+what+" from "+day.Format(time.DateOnly))
+		}
+	}
+
+	// A pause may start on the first day, while a price or plan then is
+	// that of the subscription itself.
+	for _, p := range sub.Pauses {
+		if p.StartDate.Before(start) {
+			verr.Add("start_date", "after_scheduled_change", "must not be after the pause from "+p.StartDate.Format(time.DateOnly))
+		}
+		checkEnd("pause", p.StartDate)
+	}
+	for _, pc := range sub.PriceChanges {
+		if !pc.EffectiveFrom.After(start) {
+			verr.Add("start_date", "after_scheduled_change", "must be before the price change from "+pc.EffectiveFrom.Format(time.DateOnly))
+		}
+		checkEnd("price change", pc.EffectiveFrom)
+	}
+	for _, pc := range sub.PlanChanges {
+		if !pc.EffectiveFrom.After(start) {
+			verr.Add("start_date", "after_scheduled_change", "must be before the plan change from "+pc.EffectiveFrom.Format(time.DateOnly))
+		}
+		checkEnd("plan change", pc.EffectiveFrom)
+	}
 
-		effStart := subStart
-		if effStart.Before(from) {
-			effStart = from
+	return verr
+}
+
+// create, update and delete store a validated change together with its
+// history event.
+
+func (s *subscriptionService) create(ctx context.Context, sub *domain.Subscription) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, sub); err != nil {
+			return err
 		}
-		effEnd := subEnd
-		if effEnd.After(to) {
-			effEnd = to
+		return s.record(ctx, domain.EventCreated, nil, sub)
+	})
+}
+
+func (s *subscriptionService) update(ctx context.Context, sub *domain.Subscription) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, sub.ID)
+		if err != nil {
+			return err
+		}
+		if sub.Version != 0 && sub.Version != before.Version {
+			return domain.ErrPreconditionFailed
+		}
+
+		// The dated changes stay, so they must still fit the new dates.
+		sub.Pauses, sub.PriceChanges, sub.PlanChanges = before.Pauses, before.PriceChanges, before.PlanChanges
+		if err := validateSchedule(sub).Err(); err != nil {
+			return err
 		}
 
-		if effEnd.Before(effStart) {
-			continue
+		if err := s.repo.Update(ctx, sub); err != nil {
+			return err
+		}
+		return s.record(ctx, domain.EventUpdated, before, sub)
+	})
+}
+
+func (s *subscriptionService) delete(ctx context.Context, id uuid.UUID, version int) error {
+	return s.tx.WithinTx(ctx, func(ctx context.Context) error {
+		before, err := s.repo.GetForUpdate(ctx, id)
+		if err != nil {
+			return err
 		}
+		if err := s.repo.Delete(ctx, id, version); err != nil {
+			return err
+		}
+		return s.record(ctx, domain.EventDeleted, before, nil)
+	})
+}
+
+// record appends a history event attributed to the actor and request of
+// ctx; see recordEvent.
+func (s *subscriptionService) record(ctx context.Context, typ domain.EventType, before, after *domain.Subscription) error {
+	return recordEvent(ctx, s.events, typ, before, after)
+}
+
+// recordEvent appends a history event to events attributed to the actor
+// and request of ctx. Snapshots are copied, so callers may keep changing
+// theirs.
+func recordEvent(ctx context.Context, events repo.SubscriptionEventRepository, typ domain.EventType, before, after *domain.Subscription) error {
+	e := &domain.SubscriptionEvent{
+		Type:      typ,
+		Actor:     reqctx.Actor(ctx),
+		RequestID: reqctx.RequestID(ctx),
+	}
+	if before != nil {
+		b := *before
+		e.Before, e.SubscriptionID = &b, b.ID
+	}
+	if after != nil {
+		a := *after
+		e.After, e.SubscriptionID = &a, a.ID
+	}
+
+	return events.Append(ctx, e)
+}
+
+func (s *subscriptionService) PurgeDeleted(ctx context.Context, retention time.Duration) (int64, error) {
+	return s.repo.Purge(ctx, time.Now().Add(-retention))
+}
+
+func (s *subscriptionService) TrialsEnding(ctx context.Context, userID *uuid.UUID, within int) ([]domain.Subscription, error) {
+	subs, err := s.repo.List(ctx, repo.ListFilter{
+		UserID:            userID,
+		TrialEndingWithin: &within,
+		Sort:              []repo.SortKey{{Field: string(SortByTrialEndDate)}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Subscriptions ending with their trial are already cancelled.
+	res := subs[:0]
+	for _, sub := range subs {
+		if sub.EndDate == nil || sub.EndDate.After(*sub.TrialEndDate) {
+			res = append(res, sub)
+		}
+	}
+
+	return res, nil
+}
+
+func (s *subscriptionService) List(ctx context.Context, f ListFilter) (ListResult, error) {
+	var res ListResult
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	serviceID := f.ServiceID
+	if serviceID == nil && f.ServiceNameMatch != NameMatchPrefix {
+		var err error
+		if serviceID, err = s.linkedService(ctx, f.ServiceName); err != nil {
+			return res, err
+		}
+	}
+
+	rf := repo.ListFilter{
+		UserID:            f.UserID,
+		ServiceID:         serviceID,
+		ServiceName:       f.ServiceName,
+		ServiceNameFold:   f.ServiceNameMatch == NameMatchIgnoreCase || f.ServiceNameMatch == NameMatchPrefix,
+		ServiceNamePrefix: f.ServiceNameMatch == NameMatchPrefix,
+		From:              f.From,
+		To:                f.To,
+		PriceMin:          f.PriceMin,
+		PriceMax:          f.PriceMax,
+		ActiveOn:          f.ActiveOn,
+		Status:            f.Status,
+		TrialEndingWithin: f.TrialEndingWithin,
+		IncludeDeleted:    f.IncludeDeleted,
+		OnlyDeleted:       f.OnlyDeleted,
+		// One extra row tells whether another page follows.
+		Limit:  limit + 1,
+		Offset: f.Offset,
+	}
+	for _, k := range f.Sort {
+		rf.Sort = append(rf.Sort, repo.SortKey{Field: string(k.Field), Desc: k.Desc})
+	}
+
+	if f.Cursor != "" {
+		after, err := repo.DecodeCursor(f.Cursor)
+		if err != nil {
+			return res, err
+		}
+		rf.After = after
+	}
+
+	subs, err := s.repo.List(ctx, rf)
+	if err != nil {
+		return res, err
+	}
+
+	res.TotalCount, err = s.repo.Count(ctx, rf)
+	if err != nil {
+		return res, err
+	}
+	res.Limit = limit
+	if rf.After == nil {
+		res.Offset = f.Offset
+	}
 
-		months := monthsBetweenInclusive(effStart, effEnd)
-		total += months * sub.Price
+	if len(subs) > limit {
+		subs = subs[:limit]
+		last := subs[limit-1]
+		res.NextCursor = repo.NewCursor(rf.Sort, &last).Encode()
 	}
+	res.Items = subs
 
-	return total, nil
+	return res, nil
 }
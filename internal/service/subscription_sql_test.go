@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+// TestVersionedWrites checks that a write based on a stale version fails
+// with domain.ErrPreconditionFailed, and one on a deleted subscription
+// with domain.ErrNotFound.
+func TestVersionedWrites(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	subs := repo.NewSubscriptionPostgres(db)
+
+	sub := domain.Subscription{
+		UserID: uuid.New(), ServiceName: "Versioned", Price: 100, Currency: "RUB",
+		Billing: domain.MonthlyBilling, StartDate: date(2024, time.January, 1),
+	}
+	if err := subs.Create(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	stale := sub.Version
+
+	sub.Price = 200
+	if err := subs.Update(ctx, &sub); err != nil {
+		t.Fatal(err)
+	}
+	if sub.Version != stale+1 {
+		t.Fatalf("got version %d after an update, want %d", sub.Version, stale+1)
+	}
+
+	old := sub
+	old.Version = stale
+	if err := subs.Update(ctx, &old); !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("update of a stale version: got %v, want %v", err, domain.ErrPreconditionFailed)
+	}
+	if err := subs.Delete(ctx, sub.ID, stale); !errors.Is(err, domain.ErrPreconditionFailed) {
+		t.Errorf("delete of a stale version: got %v, want %v", err, domain.ErrPreconditionFailed)
+	}
+
+	if err := subs.Delete(ctx, sub.ID, sub.Version); err != nil {
+		t.Fatal(err)
+	}
+	if err := subs.Update(ctx, &sub); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("update of a deleted subscription: got %v, want %v", err, domain.ErrNotFound)
+	}
+	if err := subs.Delete(ctx, sub.ID, 0); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("delete of a deleted subscription: got %v, want %v", err, domain.ErrNotFound)
+	}
+}
+
+// TestTrialsEndingWindow checks that a look-ahead of n days takes the
+// trials ending from today through today plus n, both included, and
+// leaves out those cancelled to end with their trial.
+func TestTrialsEndingWindow(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	subs := repo.NewSubscriptionPostgres(db)
+	svc := NewSubscriptionService(subs, repo.NewSubscriptionEventPostgres(db), repo.NewServicePostgres(db), repo.NewTxManager(db), nil)
+
+	var today time.Time
+	if err := db.QueryRow(`SELECT CURRENT_DATE`).Scan(&today); err != nil {
+		t.Fatal(err)
+	}
+	today = date(today.Year(), today.Month(), today.Day())
+
+	userID := uuid.New()
+	names := map[uuid.UUID]string{}
+	add := func(name string, trialEnd int, cancelled bool) {
+		end := today.AddDate(0, 0, trialEnd)
+		sub := domain.Subscription{
+			UserID: userID, ServiceName: name, Price: 100, Currency: "RUB", Billing: domain.MonthlyBilling,
+			StartDate: today.AddDate(0, 0, -30), TrialEndDate: &end,
+		}
+		if cancelled {
+			sub.EndDate = &end
+		}
+		if err := subs.Create(ctx, &sub); err != nil {
+			t.Fatal(err)
+		}
+		names[sub.ID] = name
+	}
+	add("ended yesterday", -1, false)
+	add("ends today", 0, false)
+	add("ends in a week", 7, false)
+	add("ends in eight days", 8, false)
+	add("ends today, cancelled", 0, true)
+
+	tests := []struct {
+		within int
+		want   []string
+	}{
+		{0, []string{"ends today"}},
+		{7, []string{"ends today", "ends in a week"}},
+		{8, []string{"ends today", "ends in a week", "ends in eight days"}},
+	}
+
+	for _, tt := range tests {
+		got, err := svc.TrialsEnding(ctx, &userID, tt.within)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var gotNames []string
+		for _, s := range got {
+			gotNames = append(gotNames, names[s.ID])
+		}
+		if !slices.Equal(gotNames, tt.want) {
+			t.Errorf("within %d days: got %q, want %q", tt.within, gotNames, tt.want)
+		}
+	}
+}
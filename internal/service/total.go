@@ -0,0 +1,332 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/RomaNano/subscriptions-aggregator/internal/domain"
+	"github.com/RomaNano/subscriptions-aggregator/internal/repo"
+)
+
+// bucket identifies amounts that are converted at the same rate.
+type bucket struct {
+	month    time.Time
+	currency string
+}
+
+// charged is what was charged at full price and the discount on it.
+type charged struct {
+	gross, discount float64
+}
+
+func (c *charged) add(cs repo.ChargeSum) {
+	c.gross += cs.Gross
+	c.discount += cs.Discount
+}
+
+// tally accumulates charged amounts per month and currency.
+type tally map[bucket]*charged
+
+func (t tally) add(cs repo.ChargeSum) {
+	b := bucket{month: cs.Month, currency: cs.Currency}
+	if t[b] == nil {
+		t[b] = new(charged)
+	}
+	t[b].add(cs)
+}
+
+// groupKey identifies a breakdown group; dimensions that are not grouped
+// on stay zero.
+type groupKey struct {
+	month       time.Time
+	serviceName string
+	userID      uuid.UUID
+}
+
+func (s *subscriptionService) Total(ctx context.Context, f TotalFilter) (TotalResult, error) {
+	t := make(tally)
+
+	err := s.charges(ctx, f, dimensions{}, t.add)
+	if err != nil {
+		return TotalResult{}, err
+	}
+
+	res, err := s.summarize(ctx, []tally{t}, f.Currency)
+	if err != nil {
+		return TotalResult{}, err
+	}
+	return res[0], nil
+}
+
+func (s *subscriptionService) Breakdown(ctx context.Context, f BreakdownFilter) (BreakdownResult, error) {
+	var (
+		byMonth bool
+		dims    dimensions
+	)
+	for _, g := range f.GroupBy {
+		switch g {
+		case GroupByMonth:
+			byMonth = true
+		case GroupByServiceName:
+			dims.service = true
+		case GroupByUserID:
+			dims.user = true
+		}
+	}
+
+	var (
+		grand  = make(tally)
+		groups = make(map[groupKey]tally)
+	)
+
+	err := s.charges(ctx, f.TotalFilter, dims, func(cs repo.ChargeSum) {
+		var k groupKey
+		if byMonth {
+			k.month = cs.Month
+		}
+		if dims.service {
+			k.serviceName = cs.ServiceName
+		}
+		if dims.user {
+			k.userID = cs.UserID
+		}
+
+		if groups[k] == nil {
+			groups[k] = make(tally)
+		}
+
+		groups[k].add(cs)
+		grand.add(cs)
+	})
+	if err != nil {
+		return BreakdownResult{}, err
+	}
+
+	keys := make([]groupKey, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if !a.month.Equal(b.month) {
+			return a.month.Before(b.month)
+		}
+		if a.serviceName != b.serviceName {
+			return a.serviceName < b.serviceName
+		}
+		return a.userID.String() < b.userID.String()
+	})
+
+	tallies := make([]tally, 0, len(keys)+1)
+	tallies = append(tallies, grand)
+	for _, k := range keys {
+		tallies = append(tallies, groups[k])
+	}
+
+	totals, err := s.summarize(ctx, tallies, f.Currency)
+	if err != nil {
+		return BreakdownResult{}, err
+	}
+
+	res := BreakdownResult{
+		GroupBy:    f.GroupBy,
+		GrandTotal: totals[0],
+		Groups:     make([]BreakdownGroup, 0, len(keys)),
+	}
+	for i, k := range keys {
+		g := BreakdownGroup{TotalResult: totals[i+1]}
+		if byMonth {
+			month := k.month
+			g.Month = &month
+		}
+		if dims.service {
+			name := k.serviceName
+			g.ServiceName = &name
+		}
+		if dims.user {
+			id := k.userID
+			g.UserID = &id
+		}
+		res.Groups = append(res.Groups, g)
+	}
+
+	return res, nil
+}
+
+// dimensions selects which attributes charges are split by besides month
+// and currency.
+type dimensions struct {
+	service bool
+	user    bool
+}
+
+// charges reports what the subscriptions matching f cost inside the
+// requested period. The default billed mode is aggregated by the
+// repository; the other modes run the in-memory engine over the rows.
+func (s *subscriptionService) charges(ctx context.Context, f TotalFilter, dims dimensions, emit func(repo.ChargeSum)) error {
+	if f.To.Before(f.From) {
+		return ErrInvalidPeriod
+	}
+	if f.Mode == "" {
+		f.Mode = TotalModeBilled
+	}
+
+	w := window{from: firstOfMonth(f.From), to: lastOfMonth(f.To)}
+	if f.Mode == TotalModeProrated {
+		w = window{from: truncateDay(f.From), to: truncateDay(f.To)}
+	}
+
+	serviceID := f.ServiceID
+	if serviceID == nil {
+		var err error
+		if serviceID, err = s.linkedService(ctx, f.ServiceName); err != nil {
+			return err
+		}
+	}
+
+	if f.Mode == TotalModeBilled {
+		sums, err := s.repo.SumCharges(ctx, repo.ChargeFilter{
+			UserID:         f.UserID,
+			ServiceID:      serviceID,
+			ServiceName:    f.ServiceName,
+			From:           w.from,
+			To:             w.to,
+			GroupByService: dims.service,
+			GroupByUser:    dims.user,
+		})
+		if err != nil {
+			return err
+		}
+		for _, cs := range sums {
+			emit(cs)
+		}
+		return nil
+	}
+
+	rf := repo.ListFilter{
+		UserID:      f.UserID,
+		ServiceID:   serviceID,
+		ServiceName: f.ServiceName,
+		From:        &w.to,
+		To:          &w.from,
+		Limit:       0,
+		Offset:      0,
+	}
+
+	subs, err := s.repo.List(ctx, rf)
+	if err != nil {
+		return err
+	}
+
+	for i := range subs {
+		sub := &subs[i]
+		eachCharge(sub, w, f.Mode, func(month time.Time, gross, discount float64) {
+			emit(repo.ChargeSum{
+				Month:       month,
+				Currency:    sub.Currency,
+				ServiceName: sub.ServiceName,
+				UserID:      sub.UserID,
+				Gross:       gross,
+				Discount:    discount,
+			})
+		})
+	}
+
+	return nil
+}
+
+// summarize turns tallies into TotalResults. When target is set, each month
+// is converted at the rate in effect at the end of it; all tallies are
+// converted in a single pass so rates are loaded once. Gross and discount
+// are rounded separately and net is their difference, so the three always
+// add up.
+func (s *subscriptionService) summarize(ctx context.Context, tallies []tally, target string) ([]TotalResult, error) {
+	target = domain.NormalizeCurrency(target)
+
+	// Flatten the tallies once, gross and discount of a bucket side by
+	// side, so converted amounts can be matched back by position.
+	var (
+		amounts []DatedAmount
+		offsets = make([]int, len(tallies)+1)
+	)
+	for i, t := range tallies {
+		for b, c := range t {
+			date := lastOfMonth(b.month)
+			amounts = append(amounts,
+				DatedAmount{Date: date, Amount: c.gross, Currency: b.currency},
+				DatedAmount{Date: date, Amount: c.discount, Currency: b.currency},
+			)
+		}
+		offsets[i+1] = len(amounts)
+	}
+
+	var converted []float64
+	if target != "" {
+		var err error
+		converted, err = s.converter.Convert(ctx, amounts, target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res := make([]TotalResult, len(tallies))
+	for i := range tallies {
+		var (
+			byCurrency = make(map[string]*charged)
+			sum        charged
+		)
+		for j := offsets[i]; j < offsets[i+1]; j += 2 {
+			cur := amounts[j].Currency
+			if byCurrency[cur] == nil {
+				byCurrency[cur] = new(charged)
+			}
+			byCurrency[cur].gross += amounts[j].Amount
+			byCurrency[cur].discount += amounts[j+1].Amount
+			if converted != nil {
+				sum.gross += converted[j]
+				sum.discount += converted[j+1]
+			}
+		}
+
+		currencies := make([]string, 0, len(byCurrency))
+		for cur := range byCurrency {
+			currencies = append(currencies, cur)
+		}
+		sort.Strings(currencies)
+
+		r := TotalResult{Subtotals: make([]Money, 0, len(currencies))}
+		for _, cur := range currencies {
+			r.Subtotals = append(r.Subtotals, toMoney(*byCurrency[cur], cur))
+		}
+
+		switch {
+		case target != "":
+			r.setTotal(toMoney(sum, target))
+
+		case len(r.Subtotals) == 1:
+			r.setTotal(r.Subtotals[0])
+		}
+
+		res[i] = r
+	}
+
+	return res, nil
+}
+
+func (r *TotalResult) setTotal(m Money) {
+	r.Total, r.Gross, r.Discount, r.Currency = m.Amount, m.Gross, m.Discount, m.Currency
+}
+
+func toMoney(c charged, currency string) Money {
+	gross, discount := int(math.Round(c.gross)), int(math.Round(c.discount))
+	return Money{
+		Amount:   gross - discount,
+		Gross:    gross,
+		Discount: discount,
+		Currency: currency,
+	}
+}
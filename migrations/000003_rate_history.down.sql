@@ -0,0 +1,10 @@
+DELETE FROM exchange_rates er
+USING exchange_rates newer
+WHERE newer.base_currency = er.base_currency
+  AND newer.quote_currency = er.quote_currency
+  AND newer.rate_date > er.rate_date;
+
+ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (base_currency, quote_currency);
+
+ALTER TABLE exchange_rates DROP COLUMN rate_date;
@@ -0,0 +1,10 @@
+-- Rates stored before this migration had no effective date and applied to
+-- every month; keep that behaviour by making them effective from the epoch.
+ALTER TABLE exchange_rates ADD COLUMN rate_date DATE;
+
+UPDATE exchange_rates SET rate_date = DATE '1970-01-01';
+
+ALTER TABLE exchange_rates ALTER COLUMN rate_date SET NOT NULL;
+
+ALTER TABLE exchange_rates DROP CONSTRAINT exchange_rates_pkey;
+ALTER TABLE exchange_rates ADD PRIMARY KEY (base_currency, quote_currency, rate_date);
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS billing_count,
+    DROP COLUMN IF EXISTS billing_unit;
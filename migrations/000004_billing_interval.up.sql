@@ -0,0 +1,5 @@
+ALTER TABLE subscriptions
+    ADD COLUMN billing_unit TEXT NOT NULL DEFAULT 'month'
+        CHECK (billing_unit IN ('week', 'month', 'year')),
+    ADD COLUMN billing_count INTEGER NOT NULL DEFAULT 1
+        CHECK (billing_count > 0);
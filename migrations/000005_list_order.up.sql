@@ -0,0 +1,2 @@
+CREATE INDEX idx_subscriptions_created_at_id
+    ON subscriptions(created_at DESC, id DESC);
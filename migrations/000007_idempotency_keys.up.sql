@@ -0,0 +1,15 @@
+CREATE TABLE idempotency_keys (
+    key TEXT PRIMARY KEY,
+    request_hash TEXT NOT NULL,
+
+    -- NULL until the first request under the key has been answered.
+    status_code INTEGER,
+    headers JSONB,
+    body BYTEA,
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    expires_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at
+    ON idempotency_keys(expires_at);
@@ -0,0 +1,4 @@
+DELETE FROM subscriptions WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS deleted_at;
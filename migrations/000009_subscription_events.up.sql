@@ -0,0 +1,18 @@
+-- Append-only history of subscription changes. There is no foreign key so
+-- that history outlives purged subscriptions.
+CREATE TABLE subscription_events (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL,
+
+    type TEXT NOT NULL
+        CHECK (type IN ('created', 'updated', 'deleted', 'restored')),
+    before JSONB,
+    after JSONB,
+
+    actor TEXT,
+    request_id TEXT,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_subscription_events_subscription_id
+    ON subscription_events(subscription_id, id);
@@ -0,0 +1,9 @@
+-- Price changes of a subscription; subscriptions.price applies before the
+-- first of them.
+CREATE TABLE subscription_prices (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+
+    PRIMARY KEY (subscription_id, effective_from)
+);
@@ -0,0 +1,10 @@
+-- Periods a subscription is not billed in; an open end_date pauses it until
+-- it is resumed.
+CREATE TABLE subscription_pauses (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    start_date DATE NOT NULL,
+    end_date DATE,
+
+    PRIMARY KEY (subscription_id, start_date),
+    CHECK (end_date IS NULL OR end_date >= start_date)
+);
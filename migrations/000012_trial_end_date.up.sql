@@ -0,0 +1,8 @@
+ALTER TABLE subscriptions
+    ADD COLUMN trial_end_date DATE,
+    ADD CONSTRAINT subscriptions_trial_end_date_check
+        CHECK (trial_end_date IS NULL OR trial_end_date >= start_date);
+
+CREATE INDEX idx_subscriptions_trial_end_date
+    ON subscriptions(trial_end_date)
+    WHERE trial_end_date IS NOT NULL;
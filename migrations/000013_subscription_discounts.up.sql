@@ -0,0 +1,20 @@
+-- Discounts on the charges of a subscription, either within a date range or
+-- for the first periods billing periods.
+CREATE TABLE subscription_discounts (
+    id BIGSERIAL PRIMARY KEY,
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+
+    kind TEXT NOT NULL CHECK (kind IN ('percent', 'fixed')),
+    value INTEGER NOT NULL CHECK (value > 0),
+
+    start_date DATE,
+    end_date DATE,
+    periods INTEGER CHECK (periods > 0),
+
+    CHECK (kind <> 'percent' OR value <= 100),
+    CHECK ((periods IS NULL) = (start_date IS NOT NULL)),
+    CHECK (end_date IS NULL OR (start_date IS NOT NULL AND end_date >= start_date))
+);
+
+CREATE INDEX idx_subscription_discounts_subscription_id
+    ON subscription_discounts(subscription_id);
@@ -0,0 +1,37 @@
+CREATE TABLE services (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+
+    name TEXT NOT NULL,
+    category TEXT,
+
+    default_price INTEGER CHECK (default_price > 0),
+    default_currency CHAR(3),
+    default_billing_unit TEXT NOT NULL DEFAULT 'month'
+        CHECK (default_billing_unit IN ('week', 'month', 'year')),
+    default_billing_count INTEGER NOT NULL DEFAULT 1
+        CHECK (default_billing_count > 0),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX idx_services_category
+    ON services(category);
+
+-- Every name a service is known by, its canonical name included, keyed by
+-- the normalized name so that names are unique across the catalog.
+CREATE TABLE service_aliases (
+    key TEXT PRIMARY KEY,
+    alias TEXT NOT NULL,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    canonical BOOLEAN NOT NULL DEFAULT false
+);
+
+CREATE INDEX idx_service_aliases_service_id
+    ON service_aliases(service_id);
+
+ALTER TABLE subscriptions
+    ADD COLUMN service_id UUID REFERENCES services(id);
+
+CREATE INDEX idx_subscriptions_service_id
+    ON subscriptions(service_id);
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS subscription_plan_changes;
+
+ALTER TABLE subscriptions
+    DROP COLUMN IF EXISTS plan_id;
+
+DROP TABLE IF EXISTS service_plans;
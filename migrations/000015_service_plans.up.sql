@@ -0,0 +1,39 @@
+CREATE TABLE service_plans (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+
+    name TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    currency CHAR(3) NOT NULL CHECK (currency ~ '^[A-Z]{3}$'),
+    billing_unit TEXT NOT NULL DEFAULT 'month'
+        CHECK (billing_unit IN ('week', 'month', 'year')),
+    billing_count INTEGER NOT NULL DEFAULT 1
+        CHECK (billing_count > 0),
+
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX idx_service_plans_name
+    ON service_plans(service_id, lower(name));
+
+ALTER TABLE subscriptions
+    ADD COLUMN plan_id UUID REFERENCES service_plans(id);
+
+-- A plan change keeps the name, price and interval the plan had when it was
+-- made, so editing a plan later does not rewrite past charges.
+CREATE TABLE subscription_plan_changes (
+    subscription_id UUID NOT NULL REFERENCES subscriptions(id) ON DELETE CASCADE,
+    effective_from DATE NOT NULL,
+
+    plan_id UUID NOT NULL REFERENCES service_plans(id),
+    plan_name TEXT NOT NULL,
+    price INTEGER NOT NULL CHECK (price > 0),
+    billing_unit TEXT NOT NULL CHECK (billing_unit IN ('week', 'month', 'year')),
+    billing_count INTEGER NOT NULL CHECK (billing_count > 0),
+
+    PRIMARY KEY (subscription_id, effective_from)
+);
+
+CREATE INDEX idx_subscription_plan_changes_plan_id
+    ON subscription_plan_changes(plan_id);
@@ -0,0 +1,10 @@
+-- A request in progress holds its key until locked_until; past it, the
+-- request is taken to have died without an answer and a retry may claim
+-- the key again. NULL once the request has been answered.
+ALTER TABLE idempotency_keys
+    ADD COLUMN locked_until TIMESTAMPTZ;
+
+-- Requests left in progress before leases existed are free to take over.
+UPDATE idempotency_keys
+SET locked_until = now()
+WHERE status_code IS NULL;
@@ -0,0 +1,2 @@
+-- The services the up migration created cannot be told from those made
+-- since, and the names it replaced are gone, so there is nothing to undo.
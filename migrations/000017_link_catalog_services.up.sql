@@ -0,0 +1,39 @@
+-- Subscriptions made before the catalog carry only a free-text name. Every
+-- name gets a service, spelled as most subscriptions spell it, unless one
+-- is already known by it; the key is domain.ServiceKey in SQL.
+WITH spellings AS (
+    SELECT lower(btrim(regexp_replace(service_name, '\s+', ' ', 'g'))) AS key,
+           btrim(regexp_replace(service_name, '\s+', ' ', 'g')) AS name,
+           count(*) AS uses,
+           min(created_at) AS first_used
+    FROM subscriptions
+    WHERE service_id IS NULL
+    GROUP BY 1, 2
+),
+fresh AS (
+    SELECT DISTINCT ON (key) key, name
+    FROM spellings
+    WHERE key <> ''
+      AND key NOT IN (SELECT key FROM service_aliases)
+    ORDER BY key, uses DESC, first_used
+),
+created AS (
+    INSERT INTO services (name)
+    SELECT name FROM fresh
+    RETURNING id, name
+)
+INSERT INTO service_aliases (key, alias, service_id, canonical)
+SELECT lower(name), name, id, true
+FROM created;
+
+-- Then they are linked under the canonical name, deleted ones included, as
+-- creating them against the catalog would have.
+UPDATE subscriptions s
+SET service_id = a.service_id,
+    service_name = c.alias,
+    version = s.version + 1,
+    updated_at = now()
+FROM service_aliases a
+JOIN service_aliases c ON c.service_id = a.service_id AND c.canonical
+WHERE s.service_id IS NULL
+  AND a.key = lower(btrim(regexp_replace(s.service_name, '\s+', ' ', 'g')));
@@ -0,0 +1,5 @@
+DELETE FROM subscription_plan_changes
+WHERE plan_id IS NULL;
+
+ALTER TABLE subscription_plan_changes
+    ALTER COLUMN plan_id SET NOT NULL;
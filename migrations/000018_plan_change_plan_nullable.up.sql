@@ -0,0 +1,4 @@
+-- A deleted subscription does not keep a service from being deleted; its
+-- plan changes keep the name, price and interval but lose the plan.
+ALTER TABLE subscription_plan_changes
+    ALTER COLUMN plan_id DROP NOT NULL;